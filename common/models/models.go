@@ -3,8 +3,14 @@ package models
 
 import (
 	"database/sql/driver"
+	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"net"
+	"time"
+
+	"gorm.io/gorm"
 )
 
 type JSONStringSlice []string
@@ -41,10 +47,264 @@ func (m JSONServiceMap) Value() (driver.Value, error) {
 	return json.Marshal(m)
 }
 
+type JSONStringMap map[string]string
+
+func (m *JSONStringMap) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan type %T into JSONStringMap", value)
+	}
+}
+
+func (m JSONStringMap) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+type JSONSourceMap map[string]uint
+
+func (m *JSONSourceMap) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan type %T into JSONSourceMap", value)
+	}
+}
+
+func (m JSONSourceMap) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
 type Host struct {
-	IP       string `gorm:"primaryKey"`
-	Hostname string
-	Labels   JSONStringSlice `gorm:"type:text"`
-	Location string
-	Services JSONServiceMap `gorm:"type:text"`
+	IP          string `gorm:"primaryKey"`
+	Hostname    string
+	Labels      JSONStringSlice `gorm:"type:text"`
+	Location    string
+	Services    JSONServiceMap `gorm:"type:text"`
+	Country     string         `gorm:"index"`
+	CountryName string
+	City        string
+	Latitude    float64
+	Longitude   float64
+	ASN         uint `gorm:"index"`
+	Org         string
+	IPNum       int64  `gorm:"index"`
+	IPHex       string `gorm:"index"`
+	FirstSeen   time.Time
+	LastSeen    time.Time `gorm:"index"`
+	// Sources maps a field ("hostname", "services.VNC", ...) to the
+	// SourceRecord it was last taken from.
+	Sources JSONSourceMap `gorm:"type:text"`
+}
+
+type SourceRecord struct {
+	ID            uint   `gorm:"primaryKey"`
+	IP            string `gorm:"index"`
+	Source        string `gorm:"index"`
+	Query         string
+	Batch         string `gorm:"index"`
+	Page          int
+	ParserVersion string
+	Payload       []byte
+	CapturedAt    time.Time `gorm:"index"`
+}
+
+// HostChange is one field of a host changing value. Cause is the query
+// that brought the change in, or "reparse".
+type HostChange struct {
+	ID        uint      `gorm:"primaryKey"`
+	IP        string    `gorm:"index"`
+	ChangedAt time.Time `gorm:"index"`
+	Field     string
+	Old       string
+	New       string
+	SourceID  uint
+	Cause     string
+}
+
+// HostService mirrors Host.Services for indexed lookups.
+type HostService struct {
+	IP   string `gorm:"primaryKey"`
+	Name string `gorm:"primaryKey;index"`
+	Port int    `gorm:"index"`
+}
+
+func (h *Host) BeforeSave(tx *gorm.DB) error {
+	h.IPNum = IPv4ToInt(h.IP)
+	h.IPHex = IPv6ToHex(h.IP)
+	return nil
+}
+
+func (h *Host) AfterSave(tx *gorm.DB) error {
+	return SyncServices(tx.Session(&gorm.Session{NewDB: true}), *h)
+}
+
+// SyncServices replaces the HostService rows of h with its Services.
+func SyncServices(tx *gorm.DB, h Host) error {
+	if err := tx.Where("ip = ?", h.IP).Delete(&HostService{}).Error; err != nil {
+		return err
+	}
+	if len(h.Services) == 0 {
+		return nil
+	}
+	rows := make([]HostService, 0, len(h.Services))
+	for name, port := range h.Services {
+		rows = append(rows, HostService{IP: h.IP, Name: name, Port: port})
+	}
+	return tx.Create(&rows).Error
+}
+
+func IPv4ToInt(s string) int64 {
+	ip := net.ParseIP(s).To4()
+	if ip == nil {
+		return 0
+	}
+	return int64(binary.BigEndian.Uint32(ip))
+}
+
+// IPv6ToHex returns an IPv6 address as sortable hex, "" for IPv4.
+func IPv6ToHex(s string) string {
+	ip := net.ParseIP(s)
+	if ip == nil || ip.To4() != nil {
+		return ""
+	}
+	return hex.EncodeToString(ip)
+}
+
+type AuditEntry struct {
+	Seq          uint64        `gorm:"primaryKey;autoIncrement:false" json:"seq"`
+	Timestamp    time.Time     `json:"timestamp"`
+	User         string        `json:"user"`
+	EngagementID string        `json:"engagement_id"`
+	Action       string        `gorm:"index" json:"action"`
+	Details      JSONStringMap `gorm:"type:text" json:"details"`
+	PrevHash     string        `json:"prev_hash"`
+	Hash         string        `json:"hash"`
+}
+
+type SavedQuery struct {
+	ID          uint   `gorm:"primaryKey"`
+	Name        string `gorm:"uniqueIndex"`
+	Query       string
+	Tags        JSONStringSlice `gorm:"type:text"`
+	Notes       string
+	LastRunAt   *time.Time
+	LastNew     int
+	LastUpdated int
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// Marker records that a one-off setup step has run.
+type Marker struct {
+	Name  string `gorm:"primaryKey"`
+	SetAt time.Time
+}
+
+type QueryRun struct {
+	ID           uint  `gorm:"primaryKey"`
+	SavedQueryID *uint `gorm:"index"`
+	Query        string
+	StartedAt    time.Time
+	FinishedAt   time.Time
+	New          int
+	Updated      int
+	Pages        int
+	Collected    int
+	Available    int
+	Credits      int
+	StoppedBy    string
+	Error        string
+	Cached       bool
+}
+
+// CreditEntry is one result page paid for on Censys.
+type CreditEntry struct {
+	ID        uint      `gorm:"primaryKey"`
+	SpentAt   time.Time `gorm:"index"`
+	Workspace string
+	Query     string
+	QueryKey  string `gorm:"index"`
+	Page      int
+	Credits   int
+}
+
+// QueryCache remembers the last complete run of a normalized query so a
+// repeat within the cache TTL can be answered from the database. It stays
+// in the workspace, since the hosts it answers with are only there.
+type QueryCache struct {
+	Key       string `gorm:"primaryKey"`
+	Query     string
+	FetchedAt time.Time
+	Pages     int
+	Collected int
+	Available int
+	Credits   int
+}
+
+type JobRun struct {
+	ID           uint      `gorm:"primaryKey"`
+	Job          string    `gorm:"index"`
+	ScheduledFor time.Time `gorm:"index"`
+	StartedAt    time.Time
+	FinishedAt   *time.Time
+	Status       string
+	Message      string
+}
+
+type Finding struct {
+	ID        uint   `gorm:"primaryKey"`
+	IP        string `gorm:"uniqueIndex:idx_finding_target"`
+	Port      int    `gorm:"uniqueIndex:idx_finding_target"`
+	State     string `gorm:"index"`
+	Hostname  string
+	Location  string
+	FirstSeen time.Time
+	LastSeen  time.Time
+	FixedAt   *time.Time
+	LastScan  string
+	// NotifiedState is the state last announced by a notification.
+	NotifiedState string
+	Triage        string          `gorm:"index"`
+	Assignee      string          `gorm:"index"`
+	Tags          JSONStringSlice `gorm:"type:text"`
+	Notes         []FindingNote
+}
+
+type FindingNote struct {
+	ID        uint `gorm:"primaryKey"`
+	FindingID uint `gorm:"index"`
+	Author    string
+	Text      string
+	CreatedAt time.Time
+}
+
+// ScanResult is the outcome of one target in a scan run.
+type ScanResult struct {
+	ID        uint `gorm:"primaryKey"`
+	ScanRunID uint `gorm:"index"`
+	IP        string
+	Port      int
+	Status    string `gorm:"index"`
+	Category  string `gorm:"index"`
+	Detail    string
+	Attempts  int
+}
+
+type ScanRun struct {
+	ID         uint      `gorm:"primaryKey"`
+	StartedAt  time.Time `gorm:"index"`
+	FinishedAt *time.Time
+	Dir        string
+	Selection  string
+	Targets    int
+	Working    int
+	Failed     int
+	Discarded  int
 }
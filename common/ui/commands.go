@@ -0,0 +1,106 @@
+// common/ui/commands.go
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"smuggr.xyz/thughunter/core/audit"
+	"smuggr.xyz/thughunter/core/config"
+	"smuggr.xyz/thughunter/core/enrich"
+)
+
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+func commands() []command {
+	return []command{
+		{"audit", "audit verify", runAudit},
+		{"query", queryUsage, runQuery},
+		{"serve", "serve", runServe},
+		{"enrich", "enrich", runEnrich},
+		{"triage", triageUsage, runTriage},
+		{"hosts", hostsUsage, runHosts},
+		{"scan", scanUsage, runScan},
+		{"config", "config show", runConfig},
+		{"workspace", workspaceUsage, runWorkspace},
+		{"selectors", selectorsUsage, runSelectors},
+		{"reparse", reparseUsage, runReparse},
+	}
+}
+
+var cfg *config.Config
+
+func Configure(c *config.Config) {
+	cfg = c
+}
+
+func RunCommand(args []string) int {
+	for _, c := range commands() {
+		if c.name != args[0] {
+			continue
+		}
+		if err := c.run(args[1:]); err != nil {
+			fmt.Println("Error:", err)
+			return 1
+		}
+		return 0
+	}
+	printUsage()
+	return 2
+}
+
+func printUsage() {
+	fmt.Println("Usage: thughunter [flags] [command]")
+	fmt.Println("Run without a command to open the interactive menu, -h lists the flags.")
+	fmt.Println("\nCommands:")
+	for _, c := range commands() {
+		fmt.Printf("  %s\n", c.usage)
+	}
+}
+
+func runEnrich(args []string) error {
+	if len(args) != 0 {
+		return errors.New("usage: enrich")
+	}
+	n, err := enrich.All()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Re-enriched %d hosts\n", n)
+	return nil
+}
+
+func runConfig(args []string) error {
+	if len(args) != 1 || args[0] != "show" {
+		return errors.New("usage: config show")
+	}
+	cfg.Show(os.Stdout)
+	return nil
+}
+
+func runAudit(args []string) error {
+	if len(args) != 1 || args[0] != "verify" {
+		return errors.New("usage: audit verify")
+	}
+
+	path := audit.LogPath()
+	rep, err := audit.Verify(path)
+	if err != nil {
+		return fmt.Errorf("verify %s: %w", path, err)
+	}
+
+	fmt.Printf("Audit log: %s (%d entries), DB: %d entries\n", path, rep.FileEntries, rep.DBEntries)
+	if rep.OK() {
+		fmt.Println("✅ Audit trail intact")
+		return nil
+	}
+	for _, p := range rep.Problems {
+		fmt.Println("[!]", p)
+	}
+	return fmt.Errorf("audit trail failed verification with %d problems", len(rep.Problems))
+}
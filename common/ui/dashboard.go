@@ -0,0 +1,285 @@
+// common/ui/dashboard.go
+package ui
+
+import (
+	"encoding/json"
+	"errors"
+	"html/template"
+	"net/http"
+	"strconv"
+
+	"smuggr.xyz/thughunter/common/models"
+	"smuggr.xyz/thughunter/core/audit"
+	"smuggr.xyz/thughunter/core/control"
+	"smuggr.xyz/thughunter/core/filter"
+	"smuggr.xyz/thughunter/core/findings"
+)
+
+type triageUpdate struct {
+	Target   string    `json:"target"`
+	Triage   *string   `json:"triage"`
+	Assignee *string   `json:"assignee"`
+	Note     *string   `json:"note"`
+	Tags     *[]string `json:"tags"`
+	Author   string    `json:"author"`
+}
+
+type dashboardData struct {
+	Findings  []models.Finding
+	Statuses  []string
+	Filter    findings.ListOptions
+	Workspace string
+
+	WorkspaceName   string
+	WorkspaceHeader string
+}
+
+var dashboardPage = template.Must(template.New("dashboard").Funcs(template.FuncMap{
+	"key": findings.Key,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="UTF-8">
+<title>ThugHunter Dashboard [{{.Workspace}}]</title>
+<style>
+body { font-family: system-ui, sans-serif; background: #1e1e1e; color: #fff; margin: 16px; }
+table { border-collapse: collapse; width: 100%; }
+td, th { border: 1px solid #444; padding: 4px 8px; font-size: 0.85rem; text-align: left; vertical-align: top; }
+input, select, button { background: #2c2c2c; color: #fff; border: 1px solid #444; border-radius: 4px; padding: 2px 6px; }
+.notes { font-size: 0.75rem; color: #bbb; max-height: 120px; overflow-y: auto; }
+.fixed { color: #06d6a0; }
+.open { color: #ff6b6b; }
+</style>
+</head>
+<body>
+<h1>Findings <small>[{{.Workspace}}]</small></h1>
+<form method="get">
+	<select name="status">
+		<option value="">any status</option>
+		{{range .Statuses}}<option value="{{.}}" {{if eq . $.Filter.Triage}}selected{{end}}>{{.}}</option>{{end}}
+	</select>
+	<input name="assignee" placeholder="assignee" value="{{.Filter.Assignee}}">
+	<input name="tag" placeholder="tag" value="{{.Filter.Tag}}">
+	<button type="submit">Filter</button>
+</form>
+<p>{{len .Findings}} findings | <a href="/hosts" style="color:#aaa">hosts</a> | <a href="/jobs" style="color:#aaa">jobs</a></p>
+<table>
+<tr><th>Target</th><th>State</th><th>Triage</th><th>Assignee</th><th>Tags</th><th>Notes</th></tr>
+{{range .Findings}}
+<tr data-id="{{.ID}}">
+	<td>{{key .IP .Port}}<br><small>{{.Hostname}} {{.Location}}</small></td>
+	<td class="{{.State}}">{{.State}}<br><small>{{.LastSeen.Format "2006-01-02 15:04"}}</small></td>
+	<td><select onchange="update({{.ID}}, {triage: this.value})">
+		{{$cur := .Triage}}{{range $.Statuses}}<option value="{{.}}" {{if eq . $cur}}selected{{end}}>{{.}}</option>{{end}}
+	</select></td>
+	<td><input value="{{.Assignee}}" onchange="update({{.ID}}, {assignee: this.value})"></td>
+	<td><input value="{{range $i, $t := .Tags}}{{if $i}}, {{end}}{{$t}}{{end}}" onchange="update({{.ID}}, {tags: this.value.split(',').map(t => t.trim()).filter(t => t)})"></td>
+	<td>
+		<div class="notes">{{range .Notes}}<div>{{.CreatedAt.Format "2006-01-02 15:04"}} <b>{{.Author}}</b>: {{.Text}}</div>{{end}}</div>
+		<input placeholder="add note" onkeydown="if (event.key === 'Enter') update({{.ID}}, {note: this.value})">
+	</td>
+</tr>
+{{end}}
+</table>
+<script>
+const WORKSPACE = {{.WorkspaceName}};
+const TOKEN_KEY = "thughunter.control_token." + WORKSPACE;
+function update(id, body) {
+	let token = localStorage.getItem(TOKEN_KEY);
+	if (!token) {
+		token = (prompt("Control server token (see control.token):") || "").trim();
+		if (!token) return;
+		localStorage.setItem(TOKEN_KEY, token);
+	}
+	fetch("/api/findings/" + id, {
+		method: "POST",
+		headers: {"Content-Type": "application/json", "Authorization": "Bearer " + token, {{.WorkspaceHeader}}: WORKSPACE},
+		body: JSON.stringify(body),
+	})
+		.then(r => {
+			if (r.status === 401) localStorage.removeItem(TOKEN_KEY);
+			return r.ok ? location.reload() : r.text().then(t => alert(t));
+		});
+}
+</script>
+</body>
+</html>`))
+
+type hostsData struct {
+	Expr      string
+	Error     string
+	Hosts     []models.Host
+	Total     int64
+	Page      int
+	Pages     int
+	Workspace string
+}
+
+var hostsPage = template.Must(template.New("hosts").Funcs(template.FuncMap{
+	"add": func(a, b int) int { return a + b },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="UTF-8">
+<title>ThugHunter Hosts [{{.Workspace}}]</title>
+<style>
+body { font-family: system-ui, sans-serif; background: #1e1e1e; color: #fff; margin: 16px; }
+table { border-collapse: collapse; width: 100%; }
+td, th { border: 1px solid #444; padding: 4px 8px; font-size: 0.85rem; text-align: left; vertical-align: top; }
+input, button { background: #2c2c2c; color: #fff; border: 1px solid #444; border-radius: 4px; padding: 2px 6px; }
+a { color: #aaa; }
+.error { color: #ff6b6b; }
+</style>
+</head>
+<body>
+<h1>Hosts <small>[{{.Workspace}}]</small></h1>
+<form method="get">
+	<input name="q" size="80" value="{{.Expr}}" placeholder='service:vnc port:5900-5910 location:"DE" cidr:10.0.0.0/8 seen:>2026-09-01 sort:-seen'>
+	<button type="submit">Filter</button>
+</form>
+{{if .Error}}<p class="error">{{.Error}}</p>{{end}}
+<p>{{.Total}} matching hosts | page {{.Page}}/{{.Pages}}
+{{if gt .Page 1}}| <a href="?q={{.Expr}}&page={{add .Page -1}}">previous</a>{{end}}
+{{if lt .Page .Pages}}| <a href="?q={{.Expr}}&page={{add .Page 1}}">next</a>{{end}}
+| <a href="/dashboard">findings</a></p>
+<table>
+<tr><th>IP</th><th>Hostname</th><th>Location</th><th>Country</th><th>ASN</th><th>Labels</th><th>Services</th><th>Last seen</th></tr>
+{{range .Hosts}}
+<tr>
+	<td>{{.IP}}</td>
+	<td>{{.Hostname}}</td>
+	<td>{{.Location}}</td>
+	<td>{{.City}} {{.Country}}</td>
+	<td>{{if .ASN}}AS{{.ASN}} {{.Org}}{{end}}</td>
+	<td>{{range .Labels}}{{.}} {{end}}</td>
+	<td>{{range $name, $port := .Services}}{{$name}}:{{$port}} {{end}}</td>
+	<td>{{if not .LastSeen.IsZero}}{{.LastSeen.Format "2006-01-02 15:04"}}{{end}}</td>
+</tr>
+{{end}}
+</table>
+</body>
+</html>`))
+
+func RegisterDashboard() {
+	http.HandleFunc("GET /hosts", control.Page(func(w http.ResponseWriter, r *http.Request) {
+		data := hostsData{Expr: r.URL.Query().Get("q"), Page: 1, Workspace: workspaceTitle()}
+		q, err := filter.Parse(data.Expr)
+		if err == nil {
+			if p, perr := strconv.Atoi(r.URL.Query().Get("page")); perr == nil && p > 0 {
+				q.Page = p
+			}
+			data.Hosts, data.Total, err = q.FindPage()
+			data.Page, data.Pages = q.Page, q.Pages(data.Total)
+		}
+		if err != nil {
+			data.Error = err.Error()
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		hostsPage.Execute(w, data)
+	}))
+
+	http.HandleFunc("GET /dashboard", control.Page(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		opts := findings.ListOptions{Triage: q.Get("status"), Assignee: q.Get("assignee"), Tag: q.Get("tag")}
+		list, err := findings.List(opts)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		dashboardPage.Execute(w, dashboardData{
+			Findings:  list,
+			Statuses:  findings.TriageStatuses,
+			Filter:    opts,
+			Workspace: workspaceTitle(),
+
+			WorkspaceName:   control.Settings().Workspace,
+			WorkspaceHeader: control.WorkspaceHeader,
+		})
+	}))
+
+	http.HandleFunc("OPTIONS /api/", control.Authorized(nil))
+
+	http.HandleFunc("GET /api/findings", control.Authorized(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		list, err := findings.List(findings.ListOptions{Triage: q.Get("status"), Assignee: q.Get("assignee"), Tag: q.Get("tag")})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, list)
+	}))
+
+	http.HandleFunc("POST /api/findings/{id}", control.Authorized(func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+		if err != nil {
+			http.Error(w, "invalid finding id", http.StatusBadRequest)
+			return
+		}
+		var upd triageUpdate
+		if err := json.NewDecoder(r.Body).Decode(&upd); err != nil {
+			http.Error(w, "invalid JSON body", http.StatusBadRequest)
+			return
+		}
+		f, err := findings.Get(uint(id))
+		handleTriageUpdate(w, f, err, upd)
+	}))
+
+	http.HandleFunc("POST /api/triage", control.Authorized(func(w http.ResponseWriter, r *http.Request) {
+		var upd triageUpdate
+		if err := json.NewDecoder(r.Body).Decode(&upd); err != nil {
+			http.Error(w, "invalid JSON body", http.StatusBadRequest)
+			return
+		}
+		f, err := findings.Lookup(upd.Target)
+		handleTriageUpdate(w, f, err, upd)
+	}))
+}
+
+func handleTriageUpdate(w http.ResponseWriter, f models.Finding, err error, upd triageUpdate) {
+	if errors.Is(err, findings.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := applyTriageUpdate(&f, upd); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, f)
+}
+
+func applyTriageUpdate(f *models.Finding, upd triageUpdate) error {
+	author := upd.Author
+	if author == "" {
+		author = audit.Operator()
+	}
+	if upd.Triage != nil {
+		if err := findings.SetTriage(f, *upd.Triage, author); err != nil {
+			return err
+		}
+	}
+	if upd.Assignee != nil && *upd.Assignee != f.Assignee {
+		if err := findings.Assign(f, *upd.Assignee, author); err != nil {
+			return err
+		}
+	}
+	if upd.Tags != nil {
+		if err := findings.SetTags(f, *upd.Tags); err != nil {
+			return err
+		}
+	}
+	if upd.Note != nil && *upd.Note != "" {
+		if err := findings.AddNote(f, author, *upd.Note); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,224 @@
+// common/ui/hosts.go
+package ui
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	"smuggr.xyz/thughunter/common/models"
+	"smuggr.xyz/thughunter/core/datastore"
+	"smuggr.xyz/thughunter/core/filter"
+	"smuggr.xyz/thughunter/core/scraper"
+)
+
+const (
+	hostsUsage   = "hosts list [-all] [filter] | hosts purge [-dry-run] <filter> | hosts show|history <ip> | hosts source <record-id>"
+	reparseUsage = "reparse [-ip addr] [-dry-run]"
+)
+
+func runHosts(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: " + hostsUsage)
+	}
+	switch args[0] {
+	case "list":
+		return hostsList(args[1:])
+	case "purge":
+		return hostsPurge(args[1:])
+	case "show":
+		if len(args) != 2 {
+			return errors.New("usage: hosts show <ip>")
+		}
+		return hostsShow(args[1])
+	case "history":
+		if len(args) != 2 {
+			return errors.New("usage: hosts history <ip>")
+		}
+		return hostsHistory(args[1])
+	case "source":
+		if len(args) != 2 {
+			return errors.New("usage: hosts source <record-id>")
+		}
+		return hostsSource(args[1])
+	default:
+		return errors.New("usage: " + hostsUsage)
+	}
+}
+
+func hostsList(args []string) error {
+	all := len(args) > 0 && (args[0] == "-all" || args[0] == "--all")
+	if all {
+		args = args[1:]
+	}
+
+	q, err := filter.Parse(strings.Join(args, " "))
+	if err != nil {
+		return err
+	}
+
+	if all {
+		hosts, err := q.FindAll()
+		if err != nil {
+			return err
+		}
+		printHosts(hosts)
+		fmt.Printf("%d matching hosts\n", len(hosts))
+		return nil
+	}
+
+	hosts, total, err := q.FindPage()
+	if err != nil {
+		return err
+	}
+	printHosts(hosts)
+	fmt.Printf("Page %d/%d, %d matching hosts (use page:N, limit:N or -all)\n", q.Page, q.Pages(total), total)
+	return nil
+}
+
+// hostsPurge deletes the hosts matching a filter and everything scraped
+// about them.
+func hostsPurge(args []string) error {
+	dryRun := len(args) > 0 && (args[0] == "-dry-run" || args[0] == "--dry-run")
+	if dryRun {
+		args = args[1:]
+	}
+	q, err := filter.Parse(strings.Join(args, " "))
+	if err != nil {
+		return err
+	}
+	if q.Empty() {
+		return errors.New("usage: hosts purge [-dry-run] <filter>")
+	}
+
+	if dryRun {
+		n, err := q.Count()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Would purge %d hosts\n", n)
+		return nil
+	}
+	n, err := q.Purge()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Purged %d hosts\n", n)
+	return nil
+}
+
+// hostsShow prints a host with the source record behind each field.
+func hostsShow(ip string) error {
+	var h models.Host
+	if err := datastore.DB.Where("ip = ?", ip).First(&h).Error; err != nil {
+		return fmt.Errorf("host %s: %w", ip, err)
+	}
+	fmt.Printf("%s  first seen %s, last seen %s\n", h.IP,
+		h.FirstSeen.Format("2006-01-02 15:04"), h.LastSeen.Format("2006-01-02 15:04"))
+
+	fields := []struct{ key, value string }{
+		{"hostname", h.Hostname},
+		{"location", h.Location},
+		{"labels", strings.Join(h.Labels, ", ")},
+	}
+	names := make([]string, 0, len(h.Services))
+	for name := range h.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fields = append(fields, struct{ key, value string }{"services." + name, fmt.Sprint(h.Services[name])})
+	}
+
+	records := make(map[uint]models.SourceRecord)
+	for _, f := range fields {
+		id, ok := h.Sources[f.key]
+		if !ok {
+			fmt.Printf("  %-20s %-30s (no source record)\n", f.key, f.value)
+			continue
+		}
+		sr, ok := records[id]
+		if !ok {
+			if err := datastore.DB.Omit("payload").First(&sr, id).Error; err != nil {
+				fmt.Printf("  %-20s %-30s record #%d missing\n", f.key, f.value, id)
+				continue
+			}
+			records[id] = sr
+		}
+		fmt.Printf("  %-20s %-30s #%d %s %s page %d, %s, query %q\n", f.key, f.value, id,
+			sr.CapturedAt.Format("2006-01-02 15:04"), sr.Source, sr.Page, sr.ParserVersion, sr.Query)
+	}
+	return nil
+}
+
+// hostsHistory prints the change timeline of a host.
+func hostsHistory(ip string) error {
+	changes, err := scraper.History(ip)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		fmt.Printf("No recorded changes for %s\n", ip)
+		return nil
+	}
+	var last string
+	for _, c := range changes {
+		stamp := c.ChangedAt.Format("2006-01-02 15:04:05")
+		if stamp != last {
+			fmt.Printf("%s  %s\n", stamp, c.Cause)
+			last = stamp
+		}
+		source := ""
+		if c.SourceID != 0 {
+			source = fmt.Sprintf(" (record #%d)", c.SourceID)
+		}
+		switch {
+		case c.Old == "":
+			fmt.Printf("  + %-20s %s%s\n", c.Field, c.New, source)
+		case c.New == "":
+			fmt.Printf("  - %-20s %s\n", c.Field, c.Old)
+		default:
+			fmt.Printf("  ~ %-20s %s -> %s%s\n", c.Field, c.Old, c.New, source)
+		}
+	}
+	return nil
+}
+
+// hostsSource prints the raw payload of a source record.
+func hostsSource(arg string) error {
+	var id uint
+	if _, err := fmt.Sscan(arg, &id); err != nil {
+		return fmt.Errorf("invalid record id %q", arg)
+	}
+	sr, raw, err := scraper.RawRecord(id)
+	if err != nil {
+		return fmt.Errorf("record %d: %w", id, err)
+	}
+	fmt.Printf("# record %d: %s from %s page %d, %s, query %q\n", sr.ID, sr.IP, sr.Source, sr.Page, sr.ParserVersion, sr.Query)
+	fmt.Println(string(raw))
+	return nil
+}
+
+func runReparse(args []string) error {
+	fs := flag.NewFlagSet("reparse", flag.ContinueOnError)
+	ip := fs.String("ip", "", "only rebuild this host")
+	dryRun := fs.Bool("dry-run", false, "list the hosts that would change without saving")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return errors.New("usage: " + reparseUsage)
+	}
+	st, err := scraper.Reparse(*ip, *dryRun)
+	if err != nil {
+		return err
+	}
+	verb := "changed"
+	if *dryRun {
+		verb = "would change"
+	}
+	fmt.Printf("Reparsed %d records for %d hosts: %d %s, %d records failed\n", st.Records, st.Hosts, st.Changed, verb, st.Failed)
+	return nil
+}
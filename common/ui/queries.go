@@ -0,0 +1,286 @@
+// common/ui/queries.go
+package ui
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"smuggr.xyz/thughunter/common/models"
+	"smuggr.xyz/thughunter/core/queries"
+	"smuggr.xyz/thughunter/core/scraper"
+)
+
+const queryUsage = "query list|add|edit|delete|stats|run|credits|import|export"
+
+func runQuery(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: " + queryUsage)
+	}
+	switch args[0] {
+	case "list":
+		return queryList()
+	case "add":
+		return queryAdd(args[1:])
+	case "edit":
+		return queryEdit(args[1:])
+	case "delete":
+		if len(args) != 2 {
+			return errors.New("usage: query delete <name>")
+		}
+		if err := queries.Delete(args[1]); err != nil {
+			return err
+		}
+		fmt.Printf("Deleted query %s\n", args[1])
+		return nil
+	case "stats":
+		if len(args) != 2 {
+			return errors.New("usage: query stats <name>")
+		}
+		return queryStats(args[1])
+	case "run":
+		return queryRun(args[1:])
+	case "credits":
+		return queryCredits()
+	case "import":
+		if len(args) != 2 {
+			return errors.New("usage: query import <file.yaml>")
+		}
+		return queryImport(args[1])
+	case "export":
+		if len(args) != 2 {
+			return errors.New("usage: query export <file.yaml>")
+		}
+		return queryExport(args[1])
+	default:
+		return errors.New("usage: " + queryUsage)
+	}
+}
+
+func queryList() error {
+	qs, err := queries.List()
+	if err != nil {
+		return err
+	}
+	for _, q := range qs {
+		st, err := queries.StatsFor(q)
+		if err != nil {
+			return err
+		}
+		lastRun := "never"
+		if q.LastRunAt != nil {
+			lastRun = q.LastRunAt.Format("2006-01-02 15:04")
+		}
+		lastNew := "never"
+		if st.LastNewAt != nil {
+			lastNew = st.LastNewAt.Format("2006-01-02 15:04")
+		}
+		fmt.Printf("%s %v\n  %s\n", q.Name, []string(q.Tags), q.Query)
+		fmt.Printf("  runs: %d, last run: %s (%d new, %d updated), total new: %d, last new host: %s\n",
+			st.Runs, lastRun, q.LastNew, q.LastUpdated, st.TotalNew, lastNew)
+		if q.Notes != "" {
+			fmt.Printf("  notes: %s\n", q.Notes)
+		}
+	}
+	fmt.Printf("%d saved queries\n", len(qs))
+	return nil
+}
+
+func queryAdd(args []string) error {
+	fs := flag.NewFlagSet("query add", flag.ContinueOnError)
+	tags := fs.String("tags", "", "comma-separated tags")
+	notes := fs.String("notes", "", "free-form notes")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return errors.New("usage: query add [-tags a,b] [-notes text] <name> <query>")
+	}
+
+	q, err := queries.Add(newSavedQuery(fs.Arg(0), fs.Arg(1), *tags, *notes))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Added query %s\n", q.Name)
+	return nil
+}
+
+func newSavedQuery(name, text, tags, notes string) models.SavedQuery {
+	return models.SavedQuery{
+		Name:  name,
+		Query: text,
+		Tags:  queries.ParseTags(tags),
+		Notes: notes,
+	}
+}
+
+func queryEdit(args []string) error {
+	fs := flag.NewFlagSet("query edit", flag.ContinueOnError)
+	name := fs.String("name", "", "rename the query")
+	text := fs.String("query", "", "replace the query text")
+	tags := fs.String("tags", "", "replace the comma-separated tags")
+	notes := fs.String("notes", "", "replace the notes")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: query edit [-name new] [-query text] [-tags a,b] [-notes text] <name>")
+	}
+
+	q, err := queries.Get(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "name":
+			q.Name = *name
+		case "query":
+			q.Query = *text
+		case "tags":
+			q.Tags = queries.ParseTags(*tags)
+		case "notes":
+			q.Notes = *notes
+		}
+	})
+	if err := queries.Update(q); err != nil {
+		return err
+	}
+	fmt.Printf("Updated query %s\n", q.Name)
+	return nil
+}
+
+func queryStats(name string) error {
+	q, err := queries.Get(name)
+	if err != nil {
+		return err
+	}
+	runs, err := queries.History(q)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("History for %s (%d runs):\n", q.Name, len(runs))
+	for _, r := range runs {
+		fmt.Printf("  %s  %4d new  %4d updated  %3d pages  %s collected  %d credits  (%s)",
+			r.StartedAt.Format("2006-01-02 15:04"), r.New, r.Updated, r.Pages, collected(r), r.Credits, r.FinishedAt.Sub(r.StartedAt).Round(time.Second))
+		if r.Cached {
+			fmt.Printf("  from cache")
+		}
+		if r.StoppedBy != "" {
+			fmt.Printf("  stopped by %s", r.StoppedBy)
+		}
+		if r.Error != "" {
+			fmt.Printf("  failed: %s", r.Error)
+		}
+		fmt.Println()
+	}
+	return nil
+}
+
+func collected(r models.QueryRun) string {
+	if r.Available > 0 {
+		return fmt.Sprintf("%d/%d", r.Collected, r.Available)
+	}
+	return strconv.Itoa(r.Collected)
+}
+
+func queryImport(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	added, updated, err := queries.Import(f)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Imported queries: %d added, %d updated\n", added, updated)
+	return nil
+}
+
+func queryExport(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := queries.Export(f); err != nil {
+		return err
+	}
+	fmt.Printf("Exported query library to %s\n", path)
+	return nil
+}
+
+func queryRun(args []string) error {
+	fs := flag.NewFlagSet("query run", flag.ContinueOnError)
+	refresh := fs.Bool("refresh", false, "query Censys even if a cached result is still fresh")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: query run [-refresh] <name,tag:x,...|all>")
+	}
+	selected, err := queries.Select(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	failed := 0
+	session, err := scraper.NewSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+	for i := range selected {
+		fmt.Printf("[%d/%d] Running query %s: %s\n", i+1, len(selected), selected[i].Name, selected[i].Query)
+		st, err := queries.Run(session, &selected[i], *refresh)
+		printImportStats(st, err)
+		if err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d/%d queries failed", failed, len(selected))
+	}
+	return nil
+}
+
+func queryCredits() error {
+	now := time.Now()
+	u, err := scraper.CreditUsage(now)
+	if err != nil {
+		return err
+	}
+	settings := cfg.Scraper
+	fmt.Printf("Today:      %s\n", creditLine(u.Day, settings.DailyCredits))
+	fmt.Printf("This month: %s\n", creditLine(u.Month, settings.MonthlyCredits))
+
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	ledger, err := scraper.Ledger(month)
+	if err != nil {
+		return err
+	}
+	if len(ledger) == 0 {
+		return nil
+	}
+	fmt.Println("\nSpent this month per query:")
+	for _, l := range ledger {
+		ws := l.Workspace
+		if ws == "" {
+			ws = "-"
+		}
+		fmt.Printf("  %5d credits  %3d pages  %-12s %s\n", l.Credits, l.Pages, ws, l.Query)
+	}
+	return nil
+}
+
+func creditLine(spent, limit int) string {
+	if limit == 0 {
+		return fmt.Sprintf("%d credits (no limit)", spent)
+	}
+	return fmt.Sprintf("%d/%d credits (%d%%)", spent, limit, spent*100/limit)
+}
@@ -0,0 +1,54 @@
+// common/ui/scan.go
+package ui
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"smuggr.xyz/thughunter/core/scanner"
+)
+
+const scanUsage = "scan [-filter expr] [-file targets.txt] [-since-last] [-host ip[:port]] [-dry-run] [-html]"
+
+func runScan(args []string) error {
+	fs := flag.NewFlagSet("scan", flag.ContinueOnError)
+	filterExpr := fs.String("filter", "", "only scan hosts matching a filter expression")
+	file := fs.String("file", "", "only scan hosts listed in a file of IPs/CIDRs")
+	sinceLast := fs.Bool("since-last", false, "only scan hosts first seen after the last finished scan")
+	host := fs.String("host", "", "retest a single host, ip or ip:port")
+	dryRun := fs.Bool("dry-run", false, "print the targets without connecting to them")
+	html := fs.Bool("html", false, "generate the HTML summary")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return errors.New("usage: " + scanUsage)
+	}
+	if *host != "" && (*filterExpr != "" || *file != "" || *sinceLast) {
+		return errors.New("-host cannot be combined with other target selections")
+	}
+
+	summary, err := scanner.Scan(scanner.ScanOptions{
+		HTML:   *html,
+		DryRun: *dryRun,
+		Targets: scanner.TargetSelection{
+			Filter:       strings.TrimSpace(*filterExpr),
+			File:         *file,
+			SinceLastRun: *sinceLast,
+			Host:         *host,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if !*dryRun {
+		fmt.Printf("%d targets: %d working, %d failed, %d discarded (%s)\n",
+			summary.Targets, len(summary.Working), len(summary.Failed), summary.Discarded, summary.Dir)
+		if b := summary.Breakdown(); len(b) > 0 {
+			fmt.Printf("Failures: %s\n", scanner.FormatBreakdown(b))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+// common/ui/selectors.go
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"smuggr.xyz/thughunter/common/models"
+	"smuggr.xyz/thughunter/core/scraper"
+)
+
+const selectorsUsage = "selectors check <page.html|capture.json>...|dump <file.yaml>"
+
+func runSelectors(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: " + selectorsUsage)
+	}
+	switch args[0] {
+	case "check":
+		if len(args) < 2 {
+			return errors.New("usage: selectors check <page.html>...")
+		}
+		return selectorsCheck(args[1:])
+	case "dump":
+		if len(args) != 2 {
+			return errors.New("usage: selectors dump <file.yaml>")
+		}
+		if err := os.WriteFile(args[1], scraper.DefaultSelectors, 0644); err != nil {
+			return err
+		}
+		fmt.Printf("Wrote built-in selectors to %s, set scraper.selectors_path to use them\n", args[1])
+		return nil
+	default:
+		return errors.New("usage: " + selectorsUsage)
+	}
+}
+
+// selectorsCheck parses saved result pages with the configured selectors,
+// so a selector file can be verified against real pages before a run.
+// Captured API responses (.json) are decoded instead.
+func selectorsCheck(paths []string) error {
+	failed := 0
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var hosts []models.Host
+		if strings.EqualFold(filepath.Ext(path), ".json") {
+			hosts, err = scraper.DecodeHosts(data)
+		} else {
+			hosts, err = scraper.ParseResults(string(data))
+		}
+		if err != nil {
+			fmt.Printf("✘ %s: %v\n", path, err)
+			failed++
+			continue
+		}
+		fmt.Printf("✔ %s: %d hosts\n", path, len(hosts))
+		printHosts(hosts)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d pages failed to parse", failed, len(paths))
+	}
+	return nil
+}
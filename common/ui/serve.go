@@ -0,0 +1,38 @@
+// common/ui/serve.go
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"smuggr.xyz/thughunter/core/scanner"
+	"smuggr.xyz/thughunter/core/scheduler"
+)
+
+func runServe(args []string) error {
+	if len(args) != 0 {
+		return errors.New("usage: serve")
+	}
+
+	jobs, err := scheduler.DefaultJobs(cfg.Schedule)
+	if err != nil {
+		return err
+	}
+	s := scheduler.New(jobs)
+	s.RegisterHandlers()
+	RegisterDashboard()
+	scanner.StartControlServer()
+	s.Start()
+
+	fmt.Println("Daemon running, press Ctrl+C to stop")
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	<-sig
+
+	fmt.Println("Stopping scheduler, waiting for running jobs to finish...")
+	s.Stop()
+	return nil
+}
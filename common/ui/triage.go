@@ -0,0 +1,124 @@
+// common/ui/triage.go
+package ui
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"smuggr.xyz/thughunter/common/models"
+	"smuggr.xyz/thughunter/core/audit"
+	"smuggr.xyz/thughunter/core/findings"
+)
+
+const triageUsage = "triage list|show|status|assign|note|tag"
+
+func runTriage(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: " + triageUsage)
+	}
+	if args[0] == "list" {
+		return triageList(args[1:])
+	}
+	if len(args) < 2 {
+		return errors.New("usage: " + triageUsage + " <ip:port> ...")
+	}
+
+	f, err := findings.Lookup(args[1])
+	if err != nil {
+		return err
+	}
+	author := audit.Operator()
+	rest := args[2:]
+
+	switch args[0] {
+	case "show":
+		printFinding(f, true)
+		return nil
+	case "status":
+		if len(rest) != 1 {
+			return fmt.Errorf("usage: triage status <ip:port> <%s>", strings.Join(findings.TriageStatuses, "|"))
+		}
+		if err := findings.SetTriage(&f, rest[0], author); err != nil {
+			return err
+		}
+	case "assign":
+		if len(rest) > 1 {
+			return errors.New("usage: triage assign <ip:port> [user]")
+		}
+		assignee := ""
+		if len(rest) == 1 {
+			assignee = rest[0]
+		}
+		if err := findings.Assign(&f, assignee, author); err != nil {
+			return err
+		}
+	case "note":
+		if len(rest) == 0 {
+			return errors.New("usage: triage note <ip:port> <text>")
+		}
+		if err := findings.AddNote(&f, author, strings.Join(rest, " ")); err != nil {
+			return err
+		}
+	case "tag":
+		if len(rest) == 0 {
+			return errors.New("usage: triage tag <ip:port> +tag -tag ...")
+		}
+		var add, remove []string
+		for _, t := range rest {
+			if name, ok := strings.CutPrefix(t, "-"); ok {
+				remove = append(remove, name)
+			} else {
+				add = append(add, strings.TrimPrefix(t, "+"))
+			}
+		}
+		if err := findings.UpdateTags(&f, add, remove); err != nil {
+			return err
+		}
+	default:
+		return errors.New("usage: " + triageUsage)
+	}
+
+	printFinding(f, false)
+	return nil
+}
+
+func triageList(args []string) error {
+	fs := flag.NewFlagSet("triage list", flag.ContinueOnError)
+	status := fs.String("status", "", "only findings with this triage status")
+	assignee := fs.String("assignee", "", "only findings assigned to this user")
+	tag := fs.String("tag", "", "only findings with this tag")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	list, err := findings.List(findings.ListOptions{Triage: *status, Assignee: *assignee, Tag: *tag})
+	if err != nil {
+		return err
+	}
+	for _, f := range list {
+		printFinding(f, false)
+	}
+	fmt.Printf("%d findings\n", len(list))
+	return nil
+}
+
+func printFinding(f models.Finding, withNotes bool) {
+	triage := f.Triage
+	if triage == "" {
+		triage = findings.TriageNew
+	}
+	assignee := f.Assignee
+	if assignee == "" {
+		assignee = "-"
+	}
+	fmt.Printf("%-22s %-6s %-15s assignee: %-12s tags: %v last seen: %s\n",
+		findings.Key(f.IP, f.Port), f.State, triage, assignee, []string(f.Tags), f.LastSeen.Format("2006-01-02 15:04"))
+	if !withNotes {
+		return
+	}
+	for _, n := range f.Notes {
+		fmt.Printf("    %s  %-10s %s\n", n.CreatedAt.Format("2006-01-02 15:04"), n.Author, n.Text)
+	}
+}
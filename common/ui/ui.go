@@ -5,18 +5,20 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"smuggr.xyz/thughunter/common/models"
-	"smuggr.xyz/thughunter/core/datastore"
+	"smuggr.xyz/thughunter/core/filter"
+	"smuggr.xyz/thughunter/core/queries"
 	"smuggr.xyz/thughunter/core/scanner"
 	"smuggr.xyz/thughunter/core/scraper"
 )
 
-func MainMenuLoop(r *bufio.Reader, predefined []string) {
+func MainMenuLoop(r *bufio.Reader) {
 	for {
 		switch showMainMenu(r) {
 		case 1:
-			launchUpdater(r, predefined)
+			launchUpdater(r)
 		case 2:
 			browseData(r)
 		case 3:
@@ -31,7 +33,7 @@ func MainMenuLoop(r *bufio.Reader, predefined []string) {
 }
 
 func showMainMenu(r *bufio.Reader) int {
-	fmt.Println("\n=== ThugHunter Main Menu ===")
+	fmt.Printf("\n=== ThugHunter Main Menu [%s] ===\n", workspaceTitle())
 	fmt.Println("1. Launch Updater")
 	fmt.Println("2. Browse Saved Data")
 	fmt.Println("3. Check VNC Services and Snapshot")
@@ -42,92 +44,167 @@ func showMainMenu(r *bufio.Reader) int {
 	return choice
 }
 
-func launchUpdater(r *bufio.Reader, predefined []string) {
+func launchUpdater(r *bufio.Reader) {
+	saved, err := queries.List()
+	if err != nil {
+		fmt.Println("Error loading query library:", err)
+		return
+	}
+
 	fmt.Println("Choose query or enter custom:")
-	for i, q := range predefined {
-		fmt.Printf("%d) %s\n", i+1, q)
+	for i, q := range saved {
+		fmt.Printf("%d) [%s] %s\n", i+1, q.Name, q.Query)
 	}
-	fmt.Printf("%d) Run all predefined queries automatically\n", len(predefined)+1)
+	fmt.Printf("%d) Run all saved queries automatically\n", len(saved)+1)
 	fmt.Print("0) Custom query\nSelect: ")
 	selStr, _ := r.ReadString('\n')
 	sel, _ := strconv.Atoi(strings.TrimSpace(selStr))
 
-	if sel == len(predefined)+1 {
-		runAllQueries(predefined)
+	if sel == len(saved)+1 {
+		runAllQueries(saved)
 		return
 	}
 
-	var query string
-	if sel > 0 && sel <= len(predefined) {
-		query = predefined[sel-1]
-	} else if sel == 0 {
-		fmt.Print("Enter custom query: ")
-		q, _ := r.ReadString('\n')
-		query = strings.TrimSpace(q)
-	} else {
+	if sel > 0 && sel <= len(saved) {
+		session, err := scraper.NewSession()
+		if err != nil {
+			fmt.Printf("[!] %v\n", err)
+			return
+		}
+		defer session.Close()
+		runSavedQuery(session, &saved[sel-1])
+		return
+	}
+	if sel != 0 {
 		fmt.Println("Invalid selection")
 		return
 	}
 
-	newCount, updCount := scraper.LaunchUpdater(query)
-	fmt.Printf("Import complete: %d new, %d updated\n", newCount, updCount)
+	fmt.Print("Enter custom query: ")
+	q, _ := r.ReadString('\n')
+	query := strings.TrimSpace(q)
+
+	started := time.Now()
+	st, err := scraper.LaunchUpdater(query, false)
+	queries.RecordRun(nil, query, started, st, err)
+	printImportStats(st, err)
+}
+
+func runSavedQuery(s *scraper.Session, q *models.SavedQuery) (scraper.Stats, error) {
+	st, err := queries.Run(s, q, false)
+	printImportStats(st, err)
+	return st, err
 }
 
-func runAllQueries(predefined []string) {
-	fmt.Printf("Running all %d predefined queries automatically...\n", len(predefined))
+func printImportStats(st scraper.Stats, err error) {
+	if err != nil {
+		fmt.Printf("[!] Import failed after %d pages (%d new, %d updated kept): %v\n", st.Pages, st.New, st.Updated, err)
+		return
+	}
+	if st.CachedAt != nil {
+		fmt.Printf("Answered from cache (%s): %d hosts already in the database, no credits spent\n", st.CachedAt.Format("2006-01-02 15:04"), st.Collected)
+		return
+	}
+	fmt.Printf("Import complete: %d new, %d updated from %d pages\n", st.New, st.Updated, st.Pages)
+	if st.StoppedBy != "" {
+		fmt.Printf("[!] Stopped by %s: collected %d of %d available results\n", st.StoppedBy, st.Collected, st.Available)
+	}
+}
+
+func runAllQueries(saved []models.SavedQuery) {
+	fmt.Printf("Running all %d saved queries automatically...\n", len(saved))
 	totalNew := 0
 	totalUpdated := 0
-
-	for i, query := range predefined {
-		fmt.Printf("\n[%d/%d] Running query: %s\n", i+1, len(predefined), query)
-		newCount, updCount := scraper.LaunchUpdater(query)
-		fmt.Printf("Query %d complete: %d new, %d updated\n", i+1, newCount, updCount)
-		totalNew += newCount
-		totalUpdated += updCount
+	failed := 0
+	summary := make([]string, len(saved))
+	session, err := scraper.NewSession()
+	if err != nil {
+		fmt.Printf("[!] %v\n", err)
+		return
+	}
+	defer session.Close()
+
+	for i := range saved {
+		fmt.Printf("\n[%d/%d] Running query %s: %s\n", i+1, len(saved), saved[i].Name, saved[i].Query)
+		st, err := runSavedQuery(session, &saved[i])
+		totalNew += st.New
+		totalUpdated += st.Updated
+		if err != nil {
+			failed++
+			summary[i] = fmt.Sprintf("✘ %s: %v", saved[i].Name, err)
+		} else {
+			summary[i] = fmt.Sprintf("✔ %s: %d new, %d updated", saved[i].Name, st.New, st.Updated)
+		}
 	}
 
 	fmt.Printf("\n=== All queries completed ===\n")
-	fmt.Printf("Total results: %d new, %d updated\n", totalNew, totalUpdated)
+	for _, line := range summary {
+		fmt.Println(line)
+	}
+	fmt.Printf("Total results: %d new, %d updated, %d/%d queries failed\n", totalNew, totalUpdated, failed, len(saved))
 }
 
 func browseData(r *bufio.Reader) {
-	fmt.Print("Filter by service (leave blank for all): ")
+	fmt.Println("Filter examples: service:vnc port:5900-5910 label:remote-access location:\"DE\" cidr:10.0.0.0/8 seen:>2026-09-01 sort:-seen")
+	fmt.Print("Filter (leave blank for all): ")
 	f, _ := r.ReadString('\n')
-	filter := strings.TrimSpace(f)
-
-	var hosts []models.Host
-	datastore.DB.Find(&hosts)
-
-	if filter == "" {
-		for _, h := range hosts {
-			fmt.Printf("IP: %s, Hostname: %s, Loc: %s, Labels: %v, Services: %v\n",
-				h.IP, h.Hostname, h.Location, h.Labels, h.Services)
-		}
 
-		fmt.Printf("Loaded %d hosts from DB\n", len(hosts))
-		fmt.Println("No filter applied, showing all hosts.")
+	q, err := filter.Parse(f)
+	if err != nil {
+		fmt.Println("Invalid filter:", err)
 		return
 	}
 
-	lowerFilter := strings.ToLower(filter)
-	matchFound := false
-	fmt.Printf("Hosts with '%s' service:\n", filter)
+	for {
+		hosts, total, err := q.FindPage()
+		if err != nil {
+			fmt.Println("Error loading hosts:", err)
+			return
+		}
+		printHosts(hosts)
+		pages := q.Pages(total)
+		fmt.Printf("Page %d/%d, %d matching hosts\n", q.Page, pages, total)
 
-	for _, h := range hosts {
-		if h.Services == nil {
-			continue
+		if pages <= 1 {
+			return
 		}
-		for svc, port := range h.Services {
-			if strings.Contains(strings.ToLower(svc), lowerFilter) {
-				fmt.Printf("%s:%d (%s)\n", h.IP, port, svc)
-				matchFound = true
+		fmt.Print("[n]ext, [p]revious, Enter to return: ")
+		nav, _ := r.ReadString('\n')
+		switch strings.TrimSpace(nav) {
+		case "n":
+			if q.Page < pages {
+				q.Page++
 			}
+		case "p":
+			if q.Page > 1 {
+				q.Page--
+			}
+		default:
+			return
 		}
 	}
+}
 
-	fmt.Printf("Loaded %d hosts from DB\n", len(hosts))
+func printHosts(hosts []models.Host) {
+	for _, h := range hosts {
+		fmt.Printf("IP: %s, Hostname: %s, Loc: %s, Geo: %s, Labels: %v, Services: %v\n",
+			h.IP, h.Hostname, h.Location, formatGeo(h), h.Labels, h.Services)
+	}
+}
 
-	if !matchFound {
-		fmt.Printf("No hosts found with service matching '%s'\n", filter)
+func formatGeo(h models.Host) string {
+	var parts []string
+	if h.City != "" {
+		parts = append(parts, h.City)
+	}
+	if h.Country != "" {
+		parts = append(parts, h.Country)
+	}
+	if h.ASN != 0 {
+		parts = append(parts, fmt.Sprintf("AS%d %s", h.ASN, h.Org))
+	}
+	if len(parts) == 0 {
+		return "-"
 	}
+	return strings.Join(parts, ", ")
 }
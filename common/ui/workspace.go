@@ -0,0 +1,102 @@
+// common/ui/workspace.go
+package ui
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"smuggr.xyz/thughunter/core/workspace"
+)
+
+const workspaceUsage = "workspace create|use|list|archive"
+
+func runWorkspace(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: " + workspaceUsage)
+	}
+	switch args[0] {
+	case "create":
+		return workspaceCreate(args[1:])
+	case "use":
+		if len(args) != 2 {
+			return errors.New("usage: workspace use <name>")
+		}
+		if err := workspace.Use(args[1]); err != nil {
+			return err
+		}
+		fmt.Printf("Now using workspace %s\n", args[1])
+		return nil
+	case "list":
+		return workspaceList()
+	case "archive":
+		if len(args) != 2 {
+			return errors.New("usage: workspace archive <name>")
+		}
+		if err := workspace.Archive(args[1]); err != nil {
+			return err
+		}
+		fmt.Printf("Archived workspace %s\n", args[1])
+		return nil
+	default:
+		return errors.New("usage: " + workspaceUsage)
+	}
+}
+
+func workspaceCreate(args []string) error {
+	fs := flag.NewFlagSet("workspace create", flag.ContinueOnError)
+	client := fs.String("client", "", "client name shown in menus and reports")
+	title := fs.String("title", "", "report title")
+	footer := fs.String("footer", "", "report footer, e.g. a confidentiality notice")
+	use := fs.Bool("use", false, "switch to the new workspace")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: workspace create [-client name] [-title text] [-footer text] [-use] <name>")
+	}
+
+	w, err := workspace.Create(fs.Arg(0), workspace.Branding{Client: *client, ReportTitle: *title, Footer: *footer})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Created workspace %s in %s\n", w.Name, w.Dir)
+	if *use {
+		if err := workspace.Use(w.Name); err != nil {
+			return err
+		}
+		fmt.Printf("Now using workspace %s\n", w.Name)
+	}
+	return nil
+}
+
+func workspaceList() error {
+	list, err := workspace.List()
+	if err != nil {
+		return err
+	}
+	active, err := workspace.Active()
+	if err != nil {
+		return err
+	}
+	for _, w := range list {
+		marker := " "
+		if w.Name == active {
+			marker = "*"
+		}
+		state := "created " + w.Created.Format("2006-01-02")
+		if w.Archived() {
+			state = "archived " + w.ArchivedAt.Format("2006-01-02")
+		}
+		fmt.Printf("%s %-20s %-30s %s\n", marker, w.Name, w.Branding.Client, state)
+	}
+	fmt.Printf("%d workspaces\n", len(list))
+	return nil
+}
+
+func workspaceTitle() string {
+	if cfg == nil || cfg.Workspace.Name == "" {
+		return "no workspace"
+	}
+	return cfg.Workspace.Title()
+}
@@ -0,0 +1,166 @@
+// core/audit/audit.go
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/user"
+	"sync"
+	"time"
+
+	"smuggr.xyz/thughunter/common/models"
+	"smuggr.xyz/thughunter/core/config"
+	"smuggr.xyz/thughunter/core/datastore"
+)
+
+const (
+	ActionUpdaterQuery = "updater.query"
+	ActionImport       = "updater.import"
+	ActionScanStart    = "scan.start"
+	ActionScanEnd      = "scan.end"
+	ActionOpenVNC      = "control.open_vnc"
+	ActionPurge        = "hosts.purge"
+	ActionQueryImport  = "query.import"
+	ActionQueryDelete  = "query.delete"
+)
+
+var (
+	mu         sync.Mutex
+	logPath    string
+	lastSeq    uint64
+	lastHash   string
+	operator   string
+	engagement string
+)
+
+func Initialize(cfg config.Audit) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	logPath = cfg.LogPath
+	operator = currentUser()
+	engagement = cfg.EngagementID
+	lastSeq, lastHash = 0, ""
+
+	err := readLog(logPath, func(e models.AuditEntry) error {
+		lastSeq, lastHash = e.Seq, e.Hash
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		log.Fatalf("failed to read audit log %s: %v", logPath, err)
+	}
+}
+
+func Record(action string, details map[string]string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if logPath == "" {
+		return
+	}
+	if details == nil {
+		details = map[string]string{}
+	}
+
+	e := models.AuditEntry{
+		Seq:          lastSeq + 1,
+		Timestamp:    time.Now().UTC().Truncate(time.Microsecond),
+		User:         operator,
+		EngagementID: engagement,
+		Action:       action,
+		Details:      details,
+		PrevHash:     lastHash,
+	}
+	e.Hash = computeHash(e)
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		fmt.Printf("[!] Failed to encode audit entry: %v\n", err)
+		return
+	}
+	if err := appendLine(logPath, line); err != nil {
+		fmt.Printf("[!] Failed to write audit log: %v\n", err)
+		return
+	}
+	lastSeq, lastHash = e.Seq, e.Hash
+
+	if err := datastore.DB.Create(&e).Error; err != nil {
+		fmt.Printf("[!] Failed to store audit entry %d in DB: %v\n", e.Seq, err)
+	}
+}
+
+func appendLine(path string, line []byte) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+func computeHash(e models.AuditEntry) string {
+	e.Hash = ""
+	data, _ := json.Marshal(e)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func readLog(path string, fn func(models.AuditEntry) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	lineNo := 0
+	for sc.Scan() {
+		lineNo++
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var e models.AuditEntry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
+
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return "unknown"
+}
+
+func Operator() string {
+	mu.Lock()
+	defer mu.Unlock()
+	if operator == "" {
+		return currentUser()
+	}
+	return operator
+}
+
+func LogPath() string {
+	mu.Lock()
+	defer mu.Unlock()
+	return logPath
+}
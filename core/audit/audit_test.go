@@ -0,0 +1,133 @@
+package audit
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"smuggr.xyz/thughunter/common/models"
+	"smuggr.xyz/thughunter/core/config"
+	"smuggr.xyz/thughunter/core/datastore"
+)
+
+// newLog starts a fresh database and log with three recorded actions and
+// returns the log path.
+func newLog(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	datastore.Initialize(filepath.Join(dir, "audit.db"))
+	path := filepath.Join(dir, "audit.jsonl")
+	Initialize(config.Audit{LogPath: path, EngagementID: "ENG-1"})
+
+	Record(ActionScanStart, map[string]string{"targets": "3"})
+	Record(ActionOpenVNC, map[string]string{"ip": "192.0.2.1", "port": "5900"})
+	Record(ActionPurge, map[string]string{"filter": "country:DE", "hosts": "2"})
+	return path
+}
+
+func readLines(t *testing.T, path string) []string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+func writeLines(t *testing.T, path string, lines []string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRecordChains(t *testing.T) {
+	path := newLog(t)
+	lines := readLines(t, path)
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want 3", len(lines))
+	}
+
+	prev := ""
+	for i, line := range lines {
+		var e models.AuditEntry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatal(err)
+		}
+		if e.Seq != uint64(i+1) || e.PrevHash != prev || e.Hash != computeHash(e) {
+			t.Errorf("entry %d: seq %d, prev %q, hash %q does not chain", i, e.Seq, e.PrevHash, e.Hash)
+		}
+		if e.EngagementID != "ENG-1" || e.User == "" {
+			t.Errorf("entry %d: engagement %q, user %q", i, e.EngagementID, e.User)
+		}
+		prev = e.Hash
+	}
+
+	// A restart continues the chain.
+	Initialize(config.Audit{LogPath: path, EngagementID: "ENG-1"})
+	Record(ActionScanEnd, nil)
+	rep, err := Verify(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rep.OK() || rep.FileEntries != 4 || rep.DBEntries != 4 {
+		t.Errorf("after restart: %+v", rep)
+	}
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(t *testing.T, path string)
+		want   string
+	}{
+		{"edited line", func(t *testing.T, path string) {
+			lines := readLines(t, path)
+			lines[1] = strings.Replace(lines[1], "192.0.2.1", "192.0.2.99", 1)
+			writeLines(t, path, lines)
+		}, "seq 2 content does not match its hash"},
+		{"edited line with a new hash", func(t *testing.T, path string) {
+			lines := readLines(t, path)
+			var e models.AuditEntry
+			json.Unmarshal([]byte(lines[1]), &e)
+			e.Details["ip"] = "192.0.2.99"
+			e.Hash = computeHash(e)
+			line, _ := json.Marshal(e)
+			lines[1] = string(line)
+			writeLines(t, path, lines)
+		}, "seq 3 does not chain to the previous entry"},
+		{"removed line", func(t *testing.T, path string) {
+			lines := readLines(t, path)
+			writeLines(t, path, append(lines[:1], lines[2:]...))
+		}, "db: seq 2 is missing from the log file"},
+		{"reordered lines", func(t *testing.T, path string) {
+			lines := readLines(t, path)
+			lines[1], lines[2] = lines[2], lines[1]
+			writeLines(t, path, lines)
+		}, "file: expected seq 2, found 3"},
+		{"edited row", func(t *testing.T, path string) {
+			datastore.DB.Model(&models.AuditEntry{}).Where("seq = ?", 3).Update("action", ActionScanEnd)
+		}, "db: seq 3 content does not match its hash"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := newLog(t)
+			if rep, err := Verify(path); err != nil || !rep.OK() {
+				t.Fatalf("untouched log: %+v, %v", rep, err)
+			}
+			tt.tamper(t, path)
+			rep, err := Verify(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, p := range rep.Problems {
+				if strings.Contains(p, tt.want) {
+					return
+				}
+			}
+			t.Errorf("problems %q do not include %q", rep.Problems, tt.want)
+		})
+	}
+}
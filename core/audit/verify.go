@@ -0,0 +1,75 @@
+// core/audit/verify.go
+package audit
+
+import (
+	"fmt"
+	"os"
+
+	"smuggr.xyz/thughunter/common/models"
+	"smuggr.xyz/thughunter/core/datastore"
+)
+
+type VerifyReport struct {
+	FileEntries int
+	DBEntries   int
+	Problems    []string
+}
+
+func (r VerifyReport) OK() bool {
+	return len(r.Problems) == 0
+}
+
+func Verify(path string) (VerifyReport, error) {
+	var rep VerifyReport
+	fileHashes := make(map[uint64]string)
+	var fileSeqs []uint64
+
+	var prevSeq uint64
+	prevHash := ""
+	err := readLog(path, func(e models.AuditEntry) error {
+		rep.FileEntries++
+		if e.Seq != prevSeq+1 {
+			rep.Problems = append(rep.Problems, fmt.Sprintf("file: expected seq %d, found %d", prevSeq+1, e.Seq))
+		}
+		if e.PrevHash != prevHash {
+			rep.Problems = append(rep.Problems, fmt.Sprintf("file: seq %d does not chain to the previous entry", e.Seq))
+		}
+		if computeHash(e) != e.Hash {
+			rep.Problems = append(rep.Problems, fmt.Sprintf("file: seq %d content does not match its hash", e.Seq))
+		}
+		fileHashes[e.Seq] = e.Hash
+		fileSeqs = append(fileSeqs, e.Seq)
+		prevSeq, prevHash = e.Seq, e.Hash
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return rep, err
+	}
+
+	var rows []models.AuditEntry
+	if err := datastore.DB.Order("seq").Find(&rows).Error; err != nil {
+		return rep, err
+	}
+	rep.DBEntries = len(rows)
+
+	seen := make(map[uint64]bool, len(rows))
+	for _, row := range rows {
+		seen[row.Seq] = true
+		if computeHash(row) != row.Hash {
+			rep.Problems = append(rep.Problems, fmt.Sprintf("db: seq %d content does not match its hash", row.Seq))
+		}
+		h, ok := fileHashes[row.Seq]
+		if !ok {
+			rep.Problems = append(rep.Problems, fmt.Sprintf("db: seq %d is missing from the log file", row.Seq))
+		} else if h != row.Hash {
+			rep.Problems = append(rep.Problems, fmt.Sprintf("db: seq %d differs from the log file", row.Seq))
+		}
+	}
+	for _, seq := range fileSeqs {
+		if !seen[seq] {
+			rep.Problems = append(rep.Problems, fmt.Sprintf("file: seq %d is missing from the DB", seq))
+		}
+	}
+
+	return rep, nil
+}
@@ -0,0 +1,336 @@
+// core/config/config.go
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+
+	"smuggr.xyz/thughunter/core/workspace"
+)
+
+var defaultFiles = []string{"thughunter.yaml", "thughunter.yml", "thughunter.toml"}
+
+// workspaceFiles are the settings that always live inside the active
+// workspace, so one engagement can never read or write another's data.
+var workspaceFiles = map[string]string{
+	"database.path":       "thughunter.db",
+	"scanner.scans_path":  "scans",
+	"schedule.scope_path": "scope.txt",
+	"audit.log_path":      "audit.jsonl",
+	"scraper.capture_dir": "captures",
+	"control.token_path":  "control.token",
+}
+
+type Database struct {
+	Path string
+}
+
+type Scanner struct {
+	ScansPath     string
+	MaxConcurrent int
+	Timeout       time.Duration
+	Attempts      int
+	RetryBackoff  time.Duration
+	RetryOn       string
+	TimeoutStep   time.Duration
+	Workspace     workspace.Workspace
+}
+
+type Scraper struct {
+	UserDataDir    string
+	MaxPages       int
+	CreditBudget   int
+	CreditsPerPage int
+	DailyCredits   int
+	MonthlyCredits int
+	CreditWarn     int
+	CacheTTL       time.Duration
+	Retries        int
+	RetryBackoff   time.Duration
+	SelectorsPath  string
+	CaptureAPI     bool
+	CapturePattern string
+	CaptureDir     string
+	LedgerPath     string
+	Workspace      string
+}
+
+type Control struct {
+	Addr          string
+	Token         string
+	TokenPath     string
+	LaunchCommand string
+	Workspace     string
+}
+
+type Audit struct {
+	LogPath      string
+	EngagementID string
+}
+
+type Schedule struct {
+	Update        string
+	UpdateQueries string
+	Scan          string
+	ScopePath     string
+}
+
+type Notify struct {
+	WebhookURL   string
+	SlackURL     string
+	TeamsURL     string
+	Retries      int
+	TemplatePath string
+}
+
+type GeoIP struct {
+	CityDB string
+	ASNDB  string
+}
+
+type Config struct {
+	File      string
+	Workspace workspace.Workspace
+	Database  Database
+	Scanner   Scanner
+	Scraper   Scraper
+	Control   Control
+	Audit     Audit
+	Schedule  Schedule
+	Notify    Notify
+	GeoIP     GeoIP
+	// Notices lists settings the workspace overrode.
+	Notices []string
+
+	sources map[string]string
+}
+
+// Load applies defaults, then the config file, then environment variables,
+// then the global flags at the start of args. It returns the remaining args.
+func Load(args []string) (*Config, []string, error) {
+	c := &Config{sources: make(map[string]string)}
+	settings := c.settings()
+
+	fs := flag.NewFlagSet("thughunter", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: thughunter [flags] [command]\n\nFlags:")
+		fs.PrintDefaults()
+	}
+	file := fs.String("config", "", "config file (YAML or TOML), defaults to ./thughunter.yaml or ./thughunter.toml")
+	flagValues := make(map[string]string)
+	for _, s := range settings {
+		key := s.key
+		fs.Func(key, s.usage, func(v string) error {
+			flagValues[key] = v
+			return nil
+		})
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	for _, s := range settings {
+		if err := s.set(s.def); err != nil {
+			panic(fmt.Sprintf("invalid default for %s: %v", s.key, err))
+		}
+		c.sources[s.key] = "default"
+	}
+
+	c.File = *file
+	if c.File == "" {
+		c.File = os.Getenv("THUGHUNTER_CONFIG")
+	}
+	if c.File == "" {
+		for _, name := range defaultFiles {
+			if _, err := os.Stat(name); err == nil {
+				c.File = name
+				break
+			}
+		}
+	}
+	if c.File != "" {
+		values, err := readFile(c.File)
+		if err != nil {
+			return nil, nil, err
+		}
+		known := make(map[string]bool, len(settings))
+		for _, s := range settings {
+			known[s.key] = true
+			if raw, ok := values[s.key]; ok {
+				if err := s.set(raw); err != nil {
+					return nil, nil, fmt.Errorf("%s: %s: %w", c.File, s.key, err)
+				}
+				c.sources[s.key] = "file " + c.File
+			}
+		}
+		for key := range values {
+			if !known[key] {
+				return nil, nil, fmt.Errorf("%s: unknown setting %q", c.File, key)
+			}
+		}
+	}
+
+	for _, s := range settings {
+		// An empty variable only overrides settings that may be empty;
+		// for the rest it counts as unset.
+		if raw, ok := os.LookupEnv(s.env); ok && (strings.TrimSpace(raw) != "" || s.emptyAllowed()) {
+			if err := s.set(raw); err != nil {
+				return nil, nil, fmt.Errorf("%s: %w", s.env, err)
+			}
+			c.sources[s.key] = "env " + s.env
+		}
+	}
+
+	for _, s := range settings {
+		if raw, ok := flagValues[s.key]; ok {
+			if err := s.set(raw); err != nil {
+				return nil, nil, fmt.Errorf("-%s: %w", s.key, err)
+			}
+			c.sources[s.key] = "flag -" + s.key
+		}
+	}
+
+	if err := c.applyWorkspace(settings); err != nil {
+		return nil, nil, err
+	}
+	return c, fs.Args(), nil
+}
+
+func (c *Config) applyWorkspace(settings []setting) error {
+	name := c.Workspace.Name
+	if name == "" {
+		active, err := workspace.Active()
+		if err != nil {
+			return err
+		}
+		if active == "" {
+			return nil
+		}
+		name = active
+		c.sources["workspace"] = "active workspace"
+	}
+	w, err := workspace.Load(name)
+	if err != nil {
+		return err
+	}
+	if w.Archived() {
+		return fmt.Errorf("%w: %s", workspace.ErrArchived, name)
+	}
+	c.Workspace = w
+
+	for _, s := range settings {
+		if file, ok := workspaceFiles[s.key]; ok {
+			source := "workspace " + w.Name
+			switch prev := c.sources[s.key]; {
+			case strings.HasPrefix(prev, "flag "):
+				return fmt.Errorf("%s conflicts with workspace %s, which keeps %s in %s", prev, w.Name, s.key, w.Dir)
+			case prev != "default":
+				source += ", ignoring " + prev
+				c.Notices = append(c.Notices, fmt.Sprintf("Workspace %s keeps %s in %s, ignoring %s from %s",
+					w.Name, s.key, w.Path(file), quote(s.String()), prev))
+			}
+			if err := s.set(w.Path(file)); err != nil {
+				return err
+			}
+			c.sources[s.key] = source
+		}
+	}
+	if c.sources["audit.engagement_id"] == "default" {
+		c.Audit.EngagementID = w.Name
+		c.sources["audit.engagement_id"] = "workspace " + w.Name
+	}
+	c.Scanner.Workspace = w
+	c.Scraper.Workspace = w.Name
+	c.Control.Workspace = w.Name
+	return nil
+}
+
+// Show prints every effective setting and where its value came from.
+// Secrets are masked.
+func (c *Config) Show(w io.Writer) {
+	if c.Workspace.Dir != "" {
+		fmt.Fprintf(w, "Workspace: %s (%s)\n", c.Workspace.Title(), c.Workspace.Dir)
+	} else {
+		fmt.Fprintln(w, "Workspace: none")
+	}
+	if c.File != "" {
+		fmt.Fprintf(w, "Config file: %s\n", c.File)
+	} else {
+		fmt.Fprintln(w, "Config file: none")
+	}
+	for _, s := range c.settings() {
+		v := s.String()
+		if s.secret && v != "" {
+			v = "********"
+		}
+		fmt.Fprintf(w, "%-24s %-40s %s\n", s.key, quote(v), c.sources[s.key])
+	}
+}
+
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	raw := make(map[string]interface{})
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("%s: unsupported config format, use .yaml or .toml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	values := make(map[string]string)
+	if err := flatten("", raw, values); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return values, nil
+}
+
+func flatten(prefix string, in map[string]interface{}, out map[string]string) error {
+	keys := make([]string, 0, len(in))
+	for k := range in {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		switch v := in[k].(type) {
+		case map[string]interface{}:
+			if err := flatten(key, v, out); err != nil {
+				return err
+			}
+		case []interface{}:
+			return errors.New(key + ": lists are not supported")
+		case nil:
+			out[key] = ""
+		default:
+			out[key] = fmt.Sprint(v)
+		}
+	}
+	return nil
+}
+
+func quote(s string) string {
+	if s == "" {
+		return `""`
+	}
+	return s
+}
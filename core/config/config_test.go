@@ -0,0 +1,140 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"smuggr.xyz/thughunter/core/workspace"
+)
+
+const testFile = `scanner:
+  timeout: 8s
+scraper:
+  capture_dir: ./file-captures
+database:
+  path: ./file.db
+`
+
+func lookup(c *Config, key string) (value, source string) {
+	for _, s := range c.settings() {
+		if s.key == key {
+			return s.String(), c.sources[key]
+		}
+	}
+	panic("unknown setting " + key)
+}
+
+// inTempDir runs the test in an empty directory, optionally with a config
+// file, so neither workspaces nor config files of the checkout leak in.
+func inTempDir(t *testing.T, file string) {
+	t.Helper()
+	t.Chdir(t.TempDir())
+	if file != "" {
+		if err := os.WriteFile("thughunter.yaml", []byte(file), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoadLayers(t *testing.T) {
+	tests := []struct {
+		name   string
+		file   string
+		env    map[string]string
+		args   []string
+		key    string
+		value  string
+		source string
+	}{
+		{"default", "", nil, nil, "scanner.timeout", "6s", "default"},
+		{"file over default", testFile, nil, nil, "scanner.timeout", "8s", "file thughunter.yaml"},
+		{"env over file", testFile, map[string]string{"TIMEOUT_DEFAULT": "10"}, nil, "scanner.timeout", "10s", "env TIMEOUT_DEFAULT"},
+		{"flag over env", testFile, map[string]string{"TIMEOUT_DEFAULT": "10"}, []string{"-scanner.timeout", "12s"}, "scanner.timeout", "12s", "flag -scanner.timeout"},
+		{"empty env duration is unset", testFile, map[string]string{"TIMEOUT_DEFAULT": ""}, nil, "scanner.timeout", "8s", "file thughunter.yaml"},
+		{"empty env path is unset", testFile, map[string]string{"DB_PATH": ""}, nil, "database.path", "./file.db", "file thughunter.yaml"},
+		{"empty env path without file", "", map[string]string{"DB_PATH": " "}, nil, "database.path", "./thughunter.db", "default"},
+		{"empty env int is unset", "", map[string]string{"UPDATER_MAX_PAGES": ""}, nil, "scraper.max_pages", "10", "default"},
+		{"empty env bool is unset", "", map[string]string{"UPDATER_CAPTURE_API": ""}, nil, "scraper.capture_api", "true", "default"},
+		{"empty env string overrides file", testFile, map[string]string{"UPDATER_CAPTURE_DIR": ""}, nil, "scraper.capture_dir", "", "env UPDATER_CAPTURE_DIR"},
+		{"empty env url overrides", "", map[string]string{"NOTIFY_SLACK_URL": ""}, nil, "notify.slack_url", "", "env NOTIFY_SLACK_URL"},
+		{"empty flag string", testFile, nil, []string{"-scraper.capture_dir", ""}, "scraper.capture_dir", "", "flag -scraper.capture_dir"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inTempDir(t, tt.file)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			c, rest, err := Load(append(tt.args, "scan"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rest) != 1 || rest[0] != "scan" {
+				t.Errorf("remaining args %q", rest)
+			}
+			value, source := lookup(c, tt.key)
+			if value != tt.value || source != tt.source {
+				t.Errorf("%s = %q from %q, want %q from %q", tt.key, value, source, tt.value, tt.source)
+			}
+		})
+	}
+}
+
+func TestLoadRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		env  map[string]string
+		args []string
+		want string
+	}{
+		{"bad env duration", "", map[string]string{"TIMEOUT_DEFAULT": "soon"}, nil, "TIMEOUT_DEFAULT"},
+		{"bad flag", "", nil, []string{"-scraper.credit_warn", "150"}, "-scraper.credit_warn"},
+		{"bad file value", "scanner:\n  attempts: 0\n", nil, nil, "scanner.attempts"},
+		{"unknown file key", "scanner:\n  speed: fast\n", nil, nil, `unknown setting "scanner.speed"`},
+		{"empty file path", "database:\n  path: \"\"\n", nil, nil, "database.path: must not be empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inTempDir(t, tt.file)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			if _, _, err := Load(tt.args); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got %v, want an error mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorkspaceOverridesPaths(t *testing.T) {
+	inTempDir(t, "")
+	w, err := workspace.Create("acme", workspace.Branding{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("THUGHUNTER_WORKSPACE", "acme")
+	t.Setenv("DB_PATH", "./shared.db")
+
+	c, _, err := Load(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	value, source := lookup(c, "database.path")
+	if value != filepath.Join(w.Dir, "thughunter.db") || source != "workspace acme, ignoring env DB_PATH" {
+		t.Errorf("database.path = %q from %q", value, source)
+	}
+	if len(c.Notices) != 1 || !strings.Contains(c.Notices[0], `ignoring ./shared.db from env DB_PATH`) {
+		t.Errorf("notices %q", c.Notices)
+	}
+	if c.Audit.EngagementID != "acme" || c.Control.Workspace != "acme" {
+		t.Errorf("engagement %q, control workspace %q", c.Audit.EngagementID, c.Control.Workspace)
+	}
+
+	_, _, err = Load([]string{"-scanner.scans_path", "/tmp/elsewhere"})
+	if err == nil || !strings.Contains(err.Error(), "flag -scanner.scans_path conflicts with workspace acme") {
+		t.Errorf("flag path in a workspace: %v", err)
+	}
+}
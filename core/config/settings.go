@@ -0,0 +1,198 @@
+// core/config/settings.go
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+type setting struct {
+	key    string
+	env    string
+	def    string
+	usage  string
+	secret bool
+	target interface{}
+	check  func(string) error
+}
+
+func (c *Config) settings() []setting {
+	return []setting{
+		{key: "workspace", env: "THUGHUNTER_WORKSPACE", usage: "workspace to use instead of the active one", target: &c.Workspace.Name},
+		{key: "database.path", env: "DB_PATH", def: "./thughunter.db", usage: "SQLite database path", target: &c.Database.Path, check: notEmpty},
+		{key: "scanner.scans_path", env: "SCANS_PATH", def: "scans", usage: "directory for scan results", target: &c.Scanner.ScansPath, check: notEmpty},
+		{key: "scanner.max_concurrent", env: "MAX_CONCURRENT_VNC", def: "0", usage: "parallel VNC snapshots, 0 picks a limit from the CPU count", target: &c.Scanner.MaxConcurrent},
+		{key: "scanner.timeout", env: "TIMEOUT_DEFAULT", def: "6s", usage: "timeout per VNC snapshot, seconds or a duration like 8s", target: &c.Scanner.Timeout},
+		{key: "scanner.attempts", env: "SCAN_ATTEMPTS", def: "1", usage: "snapshot attempts per target, 1 disables retries", target: &c.Scanner.Attempts, check: positive},
+		{key: "scanner.retry_backoff", env: "SCAN_RETRY_BACKOFF", def: "5s", usage: "wait before a target is retried, doubled after each attempt", target: &c.Scanner.RetryBackoff},
+		{key: "scanner.retry_on", env: "SCAN_RETRY_ON", def: "timeout,connection refused", usage: "comma-separated failure categories that are retried", target: &c.Scanner.RetryOn},
+		{key: "scanner.timeout_step", env: "SCAN_TIMEOUT_STEP", def: "4s", usage: "added to the snapshot timeout on every retry", target: &c.Scanner.TimeoutStep},
+		{key: "scraper.user_data_dir", env: "CDP_USER_DATA_DIR", def: "./cdp-profile", usage: "Chrome profile directory used for Censys", target: &c.Scraper.UserDataDir, check: notEmpty},
+		{key: "scraper.max_pages", env: "UPDATER_MAX_PAGES", def: "10", usage: "result pages collected per query, 0 for no limit", target: &c.Scraper.MaxPages},
+		{key: "scraper.credit_budget", env: "UPDATER_CREDIT_BUDGET", def: "0", usage: "credits one query may spend, 0 for no limit", target: &c.Scraper.CreditBudget},
+		{key: "scraper.credits_per_page", env: "UPDATER_CREDITS_PER_PAGE", def: "1", usage: "credits Censys charges per result page", target: &c.Scraper.CreditsPerPage, check: positive},
+		{key: "scraper.daily_credits", env: "UPDATER_DAILY_CREDITS", def: "0", usage: "credits all queries may spend per day, 0 for no limit", target: &c.Scraper.DailyCredits},
+		{key: "scraper.monthly_credits", env: "UPDATER_MONTHLY_CREDITS", def: "0", usage: "credits all queries may spend per calendar month, 0 for no limit", target: &c.Scraper.MonthlyCredits},
+		{key: "scraper.credit_warn", env: "UPDATER_CREDIT_WARN", def: "80", usage: "warn when this percentage of the daily or monthly credits is spent", target: &c.Scraper.CreditWarn, check: percent},
+		{key: "scraper.cache_ttl", env: "UPDATER_CACHE_TTL", def: "24h", usage: "answer a repeated query from the database within this window, 0 to always query Censys", target: &c.Scraper.CacheTTL},
+		{key: "scraper.retries", env: "UPDATER_RETRIES", def: "3", usage: "attempts per browser step before a query fails", target: &c.Scraper.Retries, check: positive},
+		{key: "scraper.retry_backoff", env: "UPDATER_RETRY_BACKOFF", def: "2s", usage: "wait before the first retry, doubled after each attempt", target: &c.Scraper.RetryBackoff},
+		{key: "scraper.selectors_path", env: "UPDATER_SELECTORS_PATH", usage: "selector file for Censys result pages, built-in when empty", target: &c.Scraper.SelectorsPath},
+		{key: "scraper.capture_api", env: "UPDATER_CAPTURE_API", def: "true", usage: "decode hosts from the search API responses the web app fetches", target: &c.Scraper.CaptureAPI},
+		{key: "scraper.capture_pattern", env: "UPDATER_CAPTURE_PATTERN", def: "/api/", usage: "URL substring of the JSON responses to capture", target: &c.Scraper.CapturePattern, check: notEmpty},
+		{key: "scraper.capture_dir", env: "UPDATER_CAPTURE_DIR", def: "./captures", usage: "directory for raw captured responses, empty to not keep them", target: &c.Scraper.CaptureDir},
+		{key: "scraper.ledger_path", env: "UPDATER_LEDGER_PATH", def: "./credits.db", usage: "credit ledger shared by all workspaces, as credits belong to the Censys account", target: &c.Scraper.LedgerPath, check: notEmpty},
+		{key: "control.addr", env: "CONTROL_SERVER_ADDR", def: "127.0.0.1:7373", usage: "control server listen address", target: &c.Control.Addr, check: hostPort},
+		{key: "control.token", env: "CONTROL_SERVER_TOKEN", usage: "control API token, generated into ./control.token when empty", secret: true, target: &c.Control.Token},
+		{key: "control.token_path", env: "CONTROL_TOKEN_PATH", def: "./control.token", usage: "file the generated control token is kept in", target: &c.Control.TokenPath, check: notEmpty},
+		{key: "control.launch_command", env: "LAUNCH_VNC_COMMAND", usage: "VNC viewer command with two %s placeholders for IP and port", target: &c.Control.LaunchCommand, check: launchCommand},
+		{key: "audit.log_path", env: "AUDIT_LOG_PATH", def: "./audit.jsonl", usage: "hash-chained audit log path", target: &c.Audit.LogPath, check: notEmpty},
+		{key: "audit.engagement_id", env: "ENGAGEMENT_ID", def: "unassigned", usage: "engagement recorded with every audit entry", target: &c.Audit.EngagementID, check: notEmpty},
+		{key: "schedule.update", env: "SCHEDULE_UPDATE", usage: "cron spec for the database update job", target: &c.Schedule.Update, check: cronSpec},
+		{key: "schedule.update_queries", env: "SCHEDULE_UPDATE_QUERIES", def: "all", usage: "saved queries run by the update job", target: &c.Schedule.UpdateQueries},
+		{key: "schedule.scan", env: "SCHEDULE_SCAN", usage: "cron spec for the scope scan job", target: &c.Schedule.Scan, check: cronSpec},
+		{key: "schedule.scope_path", env: "SCOPE_PATH", def: "./scope.txt", usage: "scope file for scheduled scans", target: &c.Schedule.ScopePath, check: notEmpty},
+		{key: "notify.webhook_url", env: "NOTIFY_WEBHOOK_URL", usage: "generic JSON webhook", secret: true, target: &c.Notify.WebhookURL, check: httpURL},
+		{key: "notify.slack_url", env: "NOTIFY_SLACK_URL", usage: "Slack incoming webhook", secret: true, target: &c.Notify.SlackURL, check: httpURL},
+		{key: "notify.teams_url", env: "NOTIFY_TEAMS_URL", usage: "Microsoft Teams incoming webhook", secret: true, target: &c.Notify.TeamsURL, check: httpURL},
+		{key: "notify.retries", env: "NOTIFY_RETRIES", def: "3", usage: "delivery attempts per notification", target: &c.Notify.Retries, check: positive},
+		{key: "notify.template_path", env: "NOTIFY_TEMPLATE_PATH", usage: "text/template file for notification messages", target: &c.Notify.TemplatePath},
+		{key: "geoip.city_db", env: "GEOIP_CITY_DB", usage: "GeoLite2 City database", target: &c.GeoIP.CityDB},
+		{key: "geoip.asn_db", env: "GEOIP_ASN_DB", usage: "GeoLite2 ASN database", target: &c.GeoIP.ASNDB},
+	}
+}
+
+func (s setting) set(raw string) error {
+	raw = strings.TrimSpace(raw)
+	if s.check != nil {
+		if err := s.check(raw); err != nil {
+			return err
+		}
+	}
+	switch t := s.target.(type) {
+	case *string:
+		*t = raw
+	case *int:
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			return fmt.Errorf("%q is not a non-negative number", raw)
+		}
+		*t = n
+	case *time.Duration:
+		d, err := parseDuration(raw)
+		if err != nil {
+			return err
+		}
+		*t = d
+	case *bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", raw)
+		}
+		*t = b
+	default:
+		panic("unsupported setting type for " + s.key)
+	}
+	return nil
+}
+
+// emptyAllowed reports whether "" is a valid value rather than a missing one.
+func (s setting) emptyAllowed() bool {
+	if _, ok := s.target.(*string); !ok {
+		return false
+	}
+	return s.check == nil || s.check("") == nil
+}
+
+func (s setting) String() string {
+	switch t := s.target.(type) {
+	case *string:
+		return *t
+	case *int:
+		return strconv.Itoa(*t)
+	case *time.Duration:
+		return t.String()
+	case *bool:
+		return strconv.FormatBool(*t)
+	}
+	return ""
+}
+
+// parseDuration accepts plain seconds, as TIMEOUT_DEFAULT always has, or a
+// Go duration.
+func parseDuration(raw string) (time.Duration, error) {
+	if n, err := strconv.Atoi(raw); err == nil {
+		if n <= 0 {
+			return 0, fmt.Errorf("%q must be positive", raw)
+		}
+		return time.Duration(n) * time.Second, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("%q is not a positive duration", raw)
+	}
+	return d, nil
+}
+
+func notEmpty(v string) error {
+	if v == "" {
+		return errors.New("must not be empty")
+	}
+	return nil
+}
+
+func positive(v string) error {
+	if n, err := strconv.Atoi(v); err != nil || n <= 0 {
+		return fmt.Errorf("%q is not a positive number", v)
+	}
+	return nil
+}
+
+func percent(v string) error {
+	if n, err := strconv.Atoi(v); err != nil || n < 1 || n > 100 {
+		return fmt.Errorf("%q is not a percentage between 1 and 100", v)
+	}
+	return nil
+}
+
+func hostPort(v string) error {
+	if _, _, err := net.SplitHostPort(v); err != nil {
+		return fmt.Errorf("%q is not a host:port address", v)
+	}
+	return nil
+}
+
+func launchCommand(v string) error {
+	if v != "" && strings.Count(v, "%s") != 2 {
+		return errors.New("must contain exactly two %s placeholders, for IP and port")
+	}
+	return nil
+}
+
+func cronSpec(v string) error {
+	if v == "" {
+		return nil
+	}
+	if _, err := cron.ParseStandard(v); err != nil {
+		return fmt.Errorf("invalid cron spec %q: %w", v, err)
+	}
+	return nil
+}
+
+func httpURL(v string) error {
+	if v == "" {
+		return nil
+	}
+	u, err := url.Parse(v)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%q is not an http(s) URL", v)
+	}
+	return nil
+}
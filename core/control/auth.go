@@ -0,0 +1,176 @@
+// core/control/auth.go
+package control
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"smuggr.xyz/thughunter/core/config"
+)
+
+// WorkspaceHeader names the workspace a browser request was made for.
+const WorkspaceHeader = "X-Thughunter-Workspace"
+
+var (
+	cfg       config.Control
+	tokenOnce sync.Once
+	token     string
+)
+
+func Configure(c config.Control) {
+	cfg = c
+}
+
+func Settings() config.Control {
+	return cfg
+}
+
+func Token() string {
+	tokenOnce.Do(func() {
+		if token = cfg.Token; token != "" {
+			return
+		}
+		if data, err := os.ReadFile(cfg.TokenPath); err == nil && len(strings.TrimSpace(string(data))) > 0 {
+			token = strings.TrimSpace(string(data))
+			return
+		}
+		buf := make([]byte, 24)
+		if _, err := rand.Read(buf); err != nil {
+			panic(fmt.Sprintf("failed to generate control token: %v", err))
+		}
+		token = hex.EncodeToString(buf)
+		if err := os.WriteFile(cfg.TokenPath, []byte(token+"\n"), 0600); err != nil {
+			fmt.Printf("[!] Failed to persist control token, reports from this session will stop working after restart: %v\n", err)
+		}
+	})
+	return token
+}
+
+func Authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !allowCORS(w, r) {
+			http.Error(w, "origin not allowed", http.StatusForbidden)
+			return
+		}
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if ws, ok := r.Header[WorkspaceHeader]; ok && (len(ws) != 1 || ws[0] != cfg.Workspace) {
+			http.Error(w, fmt.Sprintf("this server is serving workspace %q", cfg.Workspace), http.StatusConflict)
+			return
+		}
+		if !validToken(bearer(r)) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// Page guards a page opened in the browser, which cannot send the token
+// header. Without the session cookie it answers with a login form.
+func Page(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !allowCORS(w, r) {
+			http.Error(w, "origin not allowed", http.StatusForbidden)
+			return
+		}
+		if c, err := r.Cookie(sessionCookie()); (err != nil || !validToken(c.Value)) && !validToken(bearer(r)) {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.WriteHeader(http.StatusUnauthorized)
+			loginPage.Execute(w, r.URL.RequestURI())
+			return
+		}
+		next(w, r)
+	}
+}
+
+// RegisterLogin adds the form handler that trades the token for a session
+// cookie.
+func RegisterLogin() {
+	http.HandleFunc("POST /login", func(w http.ResponseWriter, r *http.Request) {
+		if !allowCORS(w, r) {
+			http.Error(w, "origin not allowed", http.StatusForbidden)
+			return
+		}
+		next := r.FormValue("next")
+		if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") {
+			next = "/dashboard"
+		}
+		if !validToken(r.FormValue("token")) {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.WriteHeader(http.StatusUnauthorized)
+			loginPage.Execute(w, next)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{
+			Name:     sessionCookie(),
+			Value:    Token(),
+			Path:     "/",
+			HttpOnly: true,
+			SameSite: http.SameSiteStrictMode,
+		})
+		http.Redirect(w, r, next, http.StatusSeeOther)
+	})
+}
+
+// sessionCookie is named per workspace, as cookies are shared by every
+// port of a host.
+func sessionCookie() string {
+	if cfg.Workspace == "" {
+		return "thughunter_session"
+	}
+	return "thughunter_session_" + cfg.Workspace
+}
+
+func bearer(r *http.Request) string {
+	return strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+}
+
+func validToken(got string) bool {
+	return got != "" && subtle.ConstantTimeCompare([]byte(got), []byte(Token())) == 1
+}
+
+var loginPage = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="UTF-8">
+<title>ThugHunter</title>
+<style>
+body { font-family: system-ui, sans-serif; background: #1e1e1e; color: #fff; margin: 16px; }
+input, button { background: #2c2c2c; color: #fff; border: 1px solid #444; border-radius: 4px; padding: 2px 6px; }
+</style>
+</head>
+<body>
+<form method="post" action="/login">
+	<input type="hidden" name="next" value="{{.}}">
+	<input type="password" name="token" size="50" placeholder="control server token (see control.token)" autofocus>
+	<button type="submit">Open</button>
+</form>
+</body>
+</html>`))
+
+// allowCORS admits reports opened from disk and the server's own pages.
+func allowCORS(w http.ResponseWriter, r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	h := w.Header()
+	h.Add("Vary", "Origin")
+	if origin == "" {
+		return true
+	}
+	if origin != "null" && origin != "file://" && origin != "http://"+r.Host {
+		return false
+	}
+	h.Set("Access-Control-Allow-Origin", origin)
+	h.Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+	h.Set("Access-Control-Allow-Headers", "Authorization, Content-Type, "+WorkspaceHeader)
+	return true
+}
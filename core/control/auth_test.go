@@ -0,0 +1,93 @@
+package control
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"smuggr.xyz/thughunter/core/config"
+)
+
+func init() {
+	Configure(config.Control{Token: "secret", Workspace: "acme"})
+}
+
+func ok(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusTeapot)
+}
+
+func TestAuthorized(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		headers map[string]string
+		want    int
+	}{
+		{"no token", "POST", nil, http.StatusUnauthorized},
+		{"wrong token", "POST", map[string]string{"Authorization": "Bearer nope"}, http.StatusUnauthorized},
+		{"token", "POST", map[string]string{"Authorization": "Bearer secret"}, http.StatusTeapot},
+		{"report on disk", "POST", map[string]string{"Authorization": "Bearer secret", "Origin": "null"}, http.StatusTeapot},
+		{"own page", "POST", map[string]string{"Authorization": "Bearer secret", "Origin": "http://127.0.0.1:7373"}, http.StatusTeapot},
+		{"other page", "POST", map[string]string{"Authorization": "Bearer secret", "Origin": "http://127.0.0.1:8000"}, http.StatusForbidden},
+		{"other preflight", "OPTIONS", map[string]string{"Origin": "https://evil.example"}, http.StatusForbidden},
+		{"preflight", "OPTIONS", map[string]string{"Origin": "null"}, http.StatusNoContent},
+		{"other workspace", "POST", map[string]string{"Authorization": "Bearer secret", WorkspaceHeader: "globex"}, http.StatusConflict},
+		{"same workspace", "POST", map[string]string{"Authorization": "Bearer secret", WorkspaceHeader: "acme"}, http.StatusTeapot},
+	}
+	h := Authorized(ok)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "http://127.0.0.1:7373/open-vnc", nil)
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			h(w, r)
+			if w.Code != tt.want {
+				t.Errorf("status %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestPageLogin(t *testing.T) {
+	http.HandleFunc("GET /dashboard", Page(ok))
+	RegisterLogin()
+	mux := http.DefaultServeMux
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "http://127.0.0.1:7373/dashboard", nil))
+	if w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), `action="/login"`) {
+		t.Fatalf("without a session got %d, want the login form", w.Code)
+	}
+
+	login := func(token, next string) *httptest.ResponseRecorder {
+		form := url.Values{"token": {token}, "next": {next}}
+		r := httptest.NewRequest("POST", "http://127.0.0.1:7373/login", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		return w
+	}
+	if w := login("nope", "/dashboard"); w.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong token got %d", w.Code)
+	}
+	w = login("secret", "//evil.example/")
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/dashboard" {
+		t.Fatalf("login got %d to %q, want a redirect to /dashboard", w.Code, w.Header().Get("Location"))
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != "thughunter_session_acme" || !cookies[0].HttpOnly {
+		t.Fatalf("login set %v", cookies)
+	}
+
+	r := httptest.NewRequest("GET", "http://127.0.0.1:7373/dashboard", nil)
+	r.AddCookie(cookies[0])
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	if w.Code != http.StatusTeapot {
+		t.Errorf("with the session cookie got %d", w.Code)
+	}
+}
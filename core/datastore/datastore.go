@@ -12,13 +12,118 @@ import (
 
 var DB *gorm.DB
 
+// Ledger holds the Censys credit ledger. Credits belong to the account,
+// not to a workspace, so every workspace shares it.
+var Ledger *gorm.DB
+
 func Initialize(path string) {
 	var err error
 	DB, err = gorm.Open(sqlite.Open(path), &gorm.Config{})
 	if err != nil {
 		log.Fatalf("failed to connect database: %v", err)
 	}
-	if err := DB.AutoMigrate(&models.Host{}); err != nil {
+	sqlDB, err := DB.DB()
+	if err != nil {
+		log.Fatalf("failed to get database handle: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	// Findings from before notifications were tracked count as announced.
+	announced := DB.Migrator().HasTable(&models.Finding{}) && !DB.Migrator().HasColumn(&models.Finding{}, "NotifiedState")
+	if err := DB.AutoMigrate(
+		&models.Host{},
+		&models.AuditEntry{},
+		&models.SavedQuery{},
+		&models.QueryRun{},
+		&models.JobRun{},
+		&models.Finding{},
+		&models.FindingNote{},
+		&models.ScanRun{},
+		&models.SourceRecord{},
+		&models.HostChange{},
+		&models.QueryCache{},
+		&models.HostService{},
+		&models.ScanResult{},
+		&models.Marker{},
+	); err != nil {
 		log.Fatalf("migrate error: %v", err)
 	}
+	if announced {
+		DB.Model(&models.Finding{}).Where("1 = 1").UpdateColumn("notified_state", gorm.Expr("state"))
+	}
+	backfillIPNum()
+	backfillHostServices()
+}
+
+// InitializeLedger opens the shared credit ledger and moves the entries an
+// older database of workspace kept into it. Call it after Initialize.
+func InitializeLedger(path, workspace string) {
+	var err error
+	// Several workspaces may run at once, wait for each other's writes.
+	Ledger, err = gorm.Open(sqlite.Open(path+"?_busy_timeout=5000"), &gorm.Config{})
+	if err != nil {
+		log.Fatalf("failed to open credit ledger: %v", err)
+	}
+	if err := Ledger.AutoMigrate(&models.CreditEntry{}); err != nil {
+		log.Fatalf("credit ledger migrate error: %v", err)
+	}
+	if DB.Migrator().HasTable(&models.CreditEntry{}) {
+		if err := moveCredits(workspace); err != nil {
+			log.Printf("failed to move credits to the shared ledger: %v", err)
+		}
+	}
+}
+
+func moveCredits(workspace string) error {
+	var entries []models.CreditEntry
+	if err := DB.Find(&entries).Error; err != nil {
+		return err
+	}
+	for i := range entries {
+		entries[i].ID = 0
+		entries[i].Workspace = workspace
+	}
+	if len(entries) > 0 {
+		if err := Ledger.CreateInBatches(entries, 500).Error; err != nil {
+			return err
+		}
+	}
+	return DB.Migrator().DropTable(&models.CreditEntry{})
+}
+
+func backfillIPNum() {
+	var batch []models.Host
+	DB.Where("ip_num = 0 AND ip NOT LIKE ?", "%:%").FindInBatches(&batch, 1000, func(tx *gorm.DB, _ int) error {
+		for _, h := range batch {
+			if err := DB.Model(&models.Host{}).Where("ip = ?", h.IP).UpdateColumn("ip_num", models.IPv4ToInt(h.IP)).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	DB.Where("(ip_hex IS NULL OR ip_hex = '') AND ip LIKE ?", "%:%").FindInBatches(&batch, 1000, func(tx *gorm.DB, _ int) error {
+		for _, h := range batch {
+			if err := DB.Model(&models.Host{}).Where("ip = ?", h.IP).UpdateColumn("ip_hex", models.IPv6ToHex(h.IP)).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// backfillHostServices fills host_services in older databases.
+func backfillHostServices() {
+	var rows int64
+	DB.Model(&models.HostService{}).Count(&rows)
+	if rows > 0 {
+		return
+	}
+	var batch []models.Host
+	DB.Where("services IS NOT NULL AND services NOT IN ('', 'null', '{}')").FindInBatches(&batch, 1000, func(tx *gorm.DB, _ int) error {
+		for _, h := range batch {
+			if err := models.SyncServices(DB, h); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
@@ -0,0 +1,122 @@
+// core/enrich/enrich.go
+package enrich
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/oschwald/maxminddb-golang"
+	"gorm.io/gorm"
+
+	"smuggr.xyz/thughunter/common/models"
+	"smuggr.xyz/thughunter/core/config"
+	"smuggr.xyz/thughunter/core/datastore"
+)
+
+type cityRecord struct {
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	Country struct {
+		ISOCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"country"`
+	Location struct {
+		Latitude  float64 `maxminddb:"latitude"`
+		Longitude float64 `maxminddb:"longitude"`
+	} `maxminddb:"location"`
+}
+
+type asnRecord struct {
+	Number uint   `maxminddb:"autonomous_system_number"`
+	Org    string `maxminddb:"autonomous_system_organization"`
+}
+
+var (
+	cfg     config.GeoIP
+	once    sync.Once
+	cityDB  *maxminddb.Reader
+	asnDB   *maxminddb.Reader
+	openErr error
+)
+
+func Configure(c config.GeoIP) {
+	cfg = c
+}
+
+func open() error {
+	once.Do(func() {
+		if path := cfg.CityDB; path != "" {
+			if cityDB, openErr = maxminddb.Open(path); openErr != nil {
+				openErr = fmt.Errorf("open GEOIP_CITY_DB: %w", openErr)
+				return
+			}
+		}
+		if path := cfg.ASNDB; path != "" {
+			if asnDB, openErr = maxminddb.Open(path); openErr != nil {
+				openErr = fmt.Errorf("open GEOIP_ASN_DB: %w", openErr)
+			}
+		}
+	})
+	return openErr
+}
+
+func Available() bool {
+	return open() == nil && (cityDB != nil || asnDB != nil)
+}
+
+func Host(h *models.Host) error {
+	if err := open(); err != nil {
+		return err
+	}
+	ip := net.ParseIP(h.IP)
+	if ip == nil {
+		return fmt.Errorf("invalid IP %q", h.IP)
+	}
+
+	if cityDB != nil {
+		var rec cityRecord
+		if err := cityDB.Lookup(ip, &rec); err != nil {
+			return fmt.Errorf("city lookup %s: %w", h.IP, err)
+		}
+		h.Country = rec.Country.ISOCode
+		h.CountryName = rec.Country.Names["en"]
+		h.City = rec.City.Names["en"]
+		h.Latitude = rec.Location.Latitude
+		h.Longitude = rec.Location.Longitude
+	}
+	if asnDB != nil {
+		var rec asnRecord
+		if err := asnDB.Lookup(ip, &rec); err != nil {
+			return fmt.Errorf("asn lookup %s: %w", h.IP, err)
+		}
+		h.ASN = rec.Number
+		h.Org = rec.Org
+	}
+	return nil
+}
+
+func All() (int, error) {
+	if err := open(); err != nil {
+		return 0, err
+	}
+	if !Available() {
+		return 0, errors.New("no GeoIP databases configured, set GEOIP_CITY_DB and/or GEOIP_ASN_DB")
+	}
+
+	enriched := 0
+	var batch []models.Host
+	res := datastore.DB.FindInBatches(&batch, 500, func(tx *gorm.DB, _ int) error {
+		for i := range batch {
+			if err := Host(&batch[i]); err != nil {
+				fmt.Printf("[!] %v\n", err)
+				continue
+			}
+			enriched++
+		}
+		return tx.Save(&batch).Error
+	})
+	return enriched, res.Error
+}
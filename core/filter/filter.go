@@ -0,0 +1,401 @@
+// core/filter/filter.go
+package filter
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"smuggr.xyz/thughunter/common/models"
+	"smuggr.xyz/thughunter/core/audit"
+	"smuggr.xyz/thughunter/core/datastore"
+)
+
+const DefaultLimit = 50
+
+var sortColumns = map[string]string{
+	"ip":       "ip_num, ip",
+	"hostname": "hostname",
+	"location": "location",
+	"country":  "country",
+	"asn":      "asn",
+	"org":      "org",
+	"seen":     "last_seen",
+	"first":    "first_seen",
+}
+
+type condition struct {
+	sql    string
+	args   []interface{}
+	negate bool
+}
+
+type Query struct {
+	Expr  string
+	conds []condition
+	sort  []string
+	Page  int
+	Limit int
+}
+
+func Parse(expr string) (*Query, error) {
+	q := &Query{Expr: strings.TrimSpace(expr), Page: 1}
+	terms, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, term := range terms {
+		negate := false
+		if strings.HasPrefix(term, "-") && len(term) > 1 {
+			negate = true
+			term = term[1:]
+		}
+
+		key, value, ok := strings.Cut(term, ":")
+		if !ok {
+			key, value = "text", term
+		}
+		key = strings.ToLower(key)
+		if value == "" {
+			return nil, fmt.Errorf("%s: missing value", key)
+		}
+
+		switch key {
+		case "sort":
+			if err := q.addSort(value); err != nil {
+				return nil, err
+			}
+			continue
+		case "page":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("page: %q is not a positive number", value)
+			}
+			q.Page = n
+			continue
+		case "limit":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("limit: %q is not a positive number", value)
+			}
+			q.Limit = n
+			continue
+		}
+
+		var alts []condition
+		for _, v := range strings.Split(value, "|") {
+			c, err := compile(key, v)
+			if err != nil {
+				return nil, err
+			}
+			alts = append(alts, c)
+		}
+		q.conds = append(q.conds, orConditions(alts, negate))
+	}
+	return q, nil
+}
+
+func (q *Query) Empty() bool {
+	return len(q.conds) == 0
+}
+
+func (q *Query) Apply(tx *gorm.DB) *gorm.DB {
+	for _, c := range q.conds {
+		if c.negate {
+			tx = tx.Where("NOT ("+c.sql+")", c.args...)
+		} else {
+			tx = tx.Where("("+c.sql+")", c.args...)
+		}
+	}
+	return tx
+}
+
+func (q *Query) Order(tx *gorm.DB) *gorm.DB {
+	if len(q.sort) == 0 {
+		return tx.Order("ip_num, ip")
+	}
+	for _, s := range q.sort {
+		tx = tx.Order(s)
+	}
+	return tx
+}
+
+func (q *Query) Paginate(tx *gorm.DB) *gorm.DB {
+	limit := q.Limit
+	if limit == 0 {
+		limit = DefaultLimit
+	}
+	return q.Order(tx).Limit(limit).Offset((q.Page - 1) * limit)
+}
+
+func (q *Query) Count() (int64, error) {
+	var n int64
+	err := q.Apply(datastore.DB.Model(&models.Host{})).Count(&n).Error
+	return n, err
+}
+
+func (q *Query) FindPage() ([]models.Host, int64, error) {
+	total, err := q.Count()
+	if err != nil {
+		return nil, 0, err
+	}
+	var hosts []models.Host
+	err = q.Paginate(q.Apply(datastore.DB.Model(&models.Host{}))).Find(&hosts).Error
+	return hosts, total, err
+}
+
+func (q *Query) FindAll() ([]models.Host, error) {
+	var hosts []models.Host
+	err := q.Order(q.Apply(datastore.DB.Model(&models.Host{}))).Find(&hosts).Error
+	return hosts, err
+}
+
+const purgeBatchSize = 500
+
+// Purge deletes the matching hosts with their services, source records and
+// change history. Findings are kept as the record of what was reported.
+func (q *Query) Purge() (int, error) {
+	if q.Empty() {
+		return 0, errors.New("refusing to purge every host, give a filter")
+	}
+	var ips []string
+	if err := q.Apply(datastore.DB.Model(&models.Host{})).Pluck("ip", &ips).Error; err != nil {
+		return 0, err
+	}
+	err := datastore.DB.Transaction(func(tx *gorm.DB) error {
+		for start := 0; start < len(ips); start += purgeBatchSize {
+			batch := ips[start:min(start+purgeBatchSize, len(ips))]
+			for _, table := range []interface{}{&models.HostService{}, &models.SourceRecord{}, &models.HostChange{}, &models.Host{}} {
+				if err := tx.Where("ip IN ?", batch).Delete(table).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	audit.Record(audit.ActionPurge, map[string]string{
+		"filter": q.Expr,
+		"hosts":  strconv.Itoa(len(ips)),
+	})
+	return len(ips), nil
+}
+
+func (q *Query) Pages(total int64) int {
+	limit := q.Limit
+	if limit == 0 {
+		limit = DefaultLimit
+	}
+	return int(math.Ceil(float64(total) / float64(limit)))
+}
+
+func (q *Query) addSort(value string) error {
+	for _, field := range strings.Split(value, ",") {
+		desc := strings.HasPrefix(field, "-")
+		col, ok := sortColumns[strings.TrimPrefix(field, "-")]
+		if !ok {
+			return fmt.Errorf("sort: unknown field %q", field)
+		}
+		if desc {
+			parts := strings.Split(col, ", ")
+			for i := range parts {
+				parts[i] += " DESC"
+			}
+			col = strings.Join(parts, ", ")
+		}
+		q.sort = append(q.sort, col)
+	}
+	return nil
+}
+
+func compile(key, value string) (condition, error) {
+	lower := strings.ToLower(value)
+	switch key {
+	case "service":
+		return condition{
+			sql:  "EXISTS (SELECT 1 FROM host_services WHERE host_services.ip = hosts.ip AND lower(host_services.name) LIKE ? ESCAPE '\\')",
+			args: []interface{}{like(lower)},
+		}, nil
+	case "port":
+		lo, hi, err := parseRange(value)
+		if err != nil {
+			return condition{}, fmt.Errorf("port: %w", err)
+		}
+		return condition{
+			sql:  "EXISTS (SELECT 1 FROM host_services WHERE host_services.ip = hosts.ip AND host_services.port BETWEEN ? AND ?)",
+			args: []interface{}{lo, hi},
+		}, nil
+	case "label":
+		return condition{
+			sql:  "EXISTS (SELECT 1 FROM json_each(CAST(hosts.labels AS TEXT)) WHERE lower(json_each.value) LIKE ? ESCAPE '\\')",
+			args: []interface{}{like(lower)},
+		}, nil
+	case "location":
+		return condition{
+			sql:  "(lower(location) LIKE ? ESCAPE '\\' OR lower(country) = ? OR lower(country_name) LIKE ? ESCAPE '\\' OR lower(city) LIKE ? ESCAPE '\\')",
+			args: []interface{}{like(lower), lower, like(lower), like(lower)},
+		}, nil
+	case "country":
+		return condition{sql: "upper(country) = ?", args: []interface{}{strings.ToUpper(value)}}, nil
+	case "city":
+		return condition{sql: "lower(city) LIKE ? ESCAPE '\\'", args: []interface{}{like(lower)}}, nil
+	case "asn":
+		n, err := strconv.ParseUint(strings.TrimPrefix(strings.ToUpper(value), "AS"), 10, 32)
+		if err != nil {
+			return condition{}, fmt.Errorf("asn: %q is not a number", value)
+		}
+		return condition{sql: "asn = ?", args: []interface{}{n}}, nil
+	case "org":
+		return condition{sql: "lower(org) LIKE ? ESCAPE '\\'", args: []interface{}{like(lower)}}, nil
+	case "hostname":
+		return condition{sql: "lower(hostname) LIKE ? ESCAPE '\\'", args: []interface{}{like(lower)}}, nil
+	case "ip":
+		if strings.Contains(value, "*") {
+			return condition{sql: "ip LIKE ? ESCAPE '\\'", args: []interface{}{strings.ReplaceAll(escapeLike(value), "*", "%")}}, nil
+		}
+		return condition{sql: "ip = ?", args: []interface{}{value}}, nil
+	case "cidr":
+		return compileCIDR(value)
+	case "seen", "first":
+		col := "last_seen"
+		if key == "first" {
+			col = "first_seen"
+		}
+		return compileDate(key, col, value)
+	case "text":
+		return condition{
+			sql:  "(ip LIKE ? ESCAPE '\\' OR lower(hostname) LIKE ? ESCAPE '\\' OR lower(location) LIKE ? ESCAPE '\\' OR lower(org) LIKE ? ESCAPE '\\')",
+			args: []interface{}{like(lower), like(lower), like(lower), like(lower)},
+		}, nil
+	default:
+		return condition{}, fmt.Errorf("unknown filter key %q", key)
+	}
+}
+
+func compileCIDR(value string) (condition, error) {
+	_, n, err := net.ParseCIDR(value)
+	if err != nil {
+		if ip := net.ParseIP(value); ip != nil {
+			return condition{sql: "ip = ?", args: []interface{}{ip.String()}}, nil
+		}
+		return condition{}, fmt.Errorf("cidr: %q is not a network", value)
+	}
+	base := n.IP.To4()
+	if base == nil {
+		hi := make(net.IP, len(n.IP))
+		for i := range n.IP {
+			hi[i] = n.IP[i] | ^n.Mask[i]
+		}
+		return condition{sql: "ip_hex BETWEEN ? AND ?", args: []interface{}{hex.EncodeToString(n.IP), hex.EncodeToString(hi)}}, nil
+	}
+	ones, _ := n.Mask.Size()
+	lo := models.IPv4ToInt(base.String())
+	hi := lo + (int64(1) << (32 - ones)) - 1
+	return condition{sql: "ip_num BETWEEN ? AND ? AND ip NOT LIKE '%:%'", args: []interface{}{lo, hi}}, nil
+}
+
+func compileDate(key, col, value string) (condition, error) {
+	op := "="
+	for _, prefix := range []string{">=", "<=", ">", "<"} {
+		if strings.HasPrefix(value, prefix) {
+			op = prefix
+			value = value[len(prefix):]
+			break
+		}
+	}
+	day, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return condition{}, fmt.Errorf("%s: %q is not a YYYY-MM-DD date", key, value)
+	}
+	next := day.AddDate(0, 0, 1)
+
+	switch op {
+	case ">":
+		return condition{sql: col + " >= ?", args: []interface{}{next}}, nil
+	case ">=":
+		return condition{sql: col + " >= ?", args: []interface{}{day}}, nil
+	case "<":
+		return condition{sql: col + " < ?", args: []interface{}{day}}, nil
+	case "<=":
+		return condition{sql: col + " < ?", args: []interface{}{next}}, nil
+	default:
+		return condition{sql: col + " >= ? AND " + col + " < ?", args: []interface{}{day, next}}, nil
+	}
+}
+
+func parseRange(value string) (int, int, error) {
+	loStr, hiStr, isRange := strings.Cut(value, "-")
+	lo, err := strconv.Atoi(loStr)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%q is not a port or range", value)
+	}
+	if !isRange {
+		return lo, lo, nil
+	}
+	hi, err := strconv.Atoi(hiStr)
+	if err != nil || hi < lo {
+		return 0, 0, fmt.Errorf("%q is not a valid range", value)
+	}
+	return lo, hi, nil
+}
+
+func orConditions(alts []condition, negate bool) condition {
+	if len(alts) == 1 {
+		alts[0].negate = negate
+		return alts[0]
+	}
+	var parts []string
+	var args []interface{}
+	for _, a := range alts {
+		parts = append(parts, "("+a.sql+")")
+		args = append(args, a.args...)
+	}
+	return condition{sql: strings.Join(parts, " OR "), args: args, negate: negate}
+}
+
+func like(s string) string {
+	return "%" + escapeLike(s) + "%"
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike makes % and _ literal under ESCAPE '\'.
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+func tokenize(expr string) ([]string, error) {
+	var terms []string
+	var cur strings.Builder
+	inQuote := false
+	for _, r := range expr {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+		case (r == ' ' || r == '\t') && !inQuote:
+			if cur.Len() > 0 {
+				terms = append(terms, cur.String())
+				cur.Reset()
+			}
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	if inQuote {
+		return nil, errors.New("unterminated quote in filter")
+	}
+	if cur.Len() > 0 {
+		terms = append(terms, cur.String())
+	}
+	return terms, nil
+}
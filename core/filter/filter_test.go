@@ -0,0 +1,174 @@
+package filter
+
+import (
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"smuggr.xyz/thughunter/common/models"
+	"smuggr.xyz/thughunter/core/datastore"
+)
+
+func TestParseRejects(t *testing.T) {
+	tests := []struct {
+		expr, want string
+	}{
+		{"colour:red", `unknown filter key "colour"`},
+		{"service:", "service: missing value"},
+		{"port:vnc", `"vnc" is not a port or range`},
+		{"port:5910-5900", `"5910-5900" is not a valid range`},
+		{"asn:google", `asn: "google" is not a number`},
+		{"cidr:10.0.0.0/33", `cidr: "10.0.0.0/33" is not a network`},
+		{"cidr:2001:db8::/129", "is not a network"},
+		{"seen:>2026-13-01", "is not a YYYY-MM-DD date"},
+		{"sort:colour", `sort: unknown field "colour"`},
+		{"page:0", "page: \"0\" is not a positive number"},
+		{"limit:-5", "limit: \"-5\" is not a positive number"},
+		{`location:"DE`, "unterminated quote"},
+		{"port:5900|x", `"x" is not a port or range`},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.expr)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Parse(%q) = %v, want an error containing %q", tt.expr, err, tt.want)
+		}
+	}
+}
+
+func TestParseTerms(t *testing.T) {
+	q, err := Parse(`location:"New York" -label:honeypot port:5900|5901 sort:-seen,ip page:3 limit:10`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(q.conds) != 3 || q.Page != 3 || q.Limit != 10 {
+		t.Fatalf("got %d conditions, page %d, limit %d", len(q.conds), q.Page, q.Limit)
+	}
+	if args := q.conds[0].args; args[0] != "%new york%" {
+		t.Errorf("quoted value compiled to %v", args)
+	}
+	if !q.conds[1].negate || q.conds[0].negate {
+		t.Error("negation not applied to the label term only")
+	}
+	if !strings.Contains(q.conds[2].sql, ") OR (") {
+		t.Errorf("alternatives compiled to %q", q.conds[2].sql)
+	}
+	if want := []string{"last_seen DESC", "ip_num, ip"}; !reflect.DeepEqual(q.sort, want) {
+		t.Errorf("sort %q, want %q", q.sort, want)
+	}
+	if empty, _ := Parse("  "); !empty.Empty() {
+		t.Error("blank filter is not empty")
+	}
+}
+
+func TestCompileCIDR(t *testing.T) {
+	tests := []struct {
+		value string
+		sql   string
+		args  []interface{}
+	}{
+		{"10.0.0.0/8", "ip_num BETWEEN", []interface{}{int64(0x0a000000), int64(0x0affffff)}},
+		{"192.0.2.77/24", "ip_num BETWEEN", []interface{}{int64(0xc0000200), int64(0xc00002ff)}},
+		{"192.0.2.1/32", "ip_num BETWEEN", []interface{}{int64(0xc0000201), int64(0xc0000201)}},
+		{"0.0.0.0/0", "ip_num BETWEEN", []interface{}{int64(0), int64(0xffffffff)}},
+		{"2001:db8::/32", "ip_hex BETWEEN", []interface{}{
+			"20010db8000000000000000000000000", "20010db8ffffffffffffffffffffffff"}},
+		{"2001:db8:0:1::/64", "ip_hex BETWEEN", []interface{}{
+			"20010db8000000010000000000000000", "20010db800000001ffffffffffffffff"}},
+		{"2001:db8::1/128", "ip_hex BETWEEN", []interface{}{
+			"20010db8000000000000000000000001", "20010db8000000000000000000000001"}},
+		{"2001:DB8::1", "ip = ?", []interface{}{"2001:db8::1"}},
+	}
+	for _, tt := range tests {
+		c, err := compileCIDR(tt.value)
+		if err != nil {
+			t.Errorf("%s: %v", tt.value, err)
+			continue
+		}
+		if !strings.HasPrefix(c.sql, tt.sql) || !reflect.DeepEqual(c.args, tt.args) {
+			t.Errorf("%s compiled to %q %v, want %q %v", tt.value, c.sql, c.args, tt.sql, tt.args)
+		}
+	}
+}
+
+func TestLikeEscaping(t *testing.T) {
+	tests := map[string]string{
+		"plain":  "%plain%",
+		"50%":    `%50\%%`,
+		"a_b":    `%a\_b%`,
+		`c:\vnc`: `%c:\\vnc%`,
+	}
+	for in, want := range tests {
+		if got := like(in); got != want {
+			t.Errorf("like(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestFilterMatches(t *testing.T) {
+	datastore.Initialize(filepath.Join(t.TempDir(), "filter.db"))
+	seen := time.Date(2026, 9, 15, 12, 0, 0, 0, time.Local)
+	hosts := []models.Host{
+		{IP: "10.1.2.3", Hostname: "100%-uptime.example", Country: "DE", City: "Berlin", ASN: 3320, LastSeen: seen,
+			Labels: models.JSONStringSlice{"remote-access"}, Services: models.JSONServiceMap{"VNC": 5900}},
+		{IP: "10.1.2.30", Hostname: "1000-uptime.example", Country: "DE", LastSeen: seen.AddDate(0, 0, 10),
+			Services: models.JSONServiceMap{"VNC": 5901, "HTTP": 80}},
+		{IP: "192.0.2.1", Hostname: "lab_vnc.example", Country: "FR", LastSeen: seen,
+			Services: models.JSONServiceMap{"VNC": 5999}},
+		{IP: "192.0.2.10", Hostname: "labxvnc.example", Country: "FR", LastSeen: seen,
+			Services: models.JSONServiceMap{"SSH": 22}},
+		{IP: "2001:db8::1", Hostname: "v6.example", LastSeen: seen, Services: models.JSONServiceMap{"VNC": 5900}},
+		{IP: "2001:db8:1::1", LastSeen: seen, Services: models.JSONServiceMap{"VNC": 5900}},
+		{IP: "2001:db9::1", LastSeen: seen, Services: models.JSONServiceMap{"VNC": 5900}},
+	}
+	for i := range hosts {
+		if err := datastore.DB.Create(&hosts[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		expr string
+		want []string
+	}{
+		{"cidr:10.0.0.0/8", []string{"10.1.2.3", "10.1.2.30"}},
+		{"cidr:192.0.2.0/28", []string{"192.0.2.1", "192.0.2.10"}},
+		{"cidr:2001:db8::/32", []string{"2001:db8:1::1", "2001:db8::1"}},
+		{"cidr:2001:db8::/48", []string{"2001:db8::1"}},
+		{"-cidr:2001:db8::/32 cidr:2000::/3", []string{"2001:db9::1"}},
+		{"hostname:100%", []string{"10.1.2.3"}},
+		{"hostname:lab_vnc", []string{"192.0.2.1"}},
+		{"lab_", []string{"192.0.2.1"}},
+		{"ip:192.0.2.*", []string{"192.0.2.1", "192.0.2.10"}},
+		{"ip:2001:db8::1", []string{"2001:db8::1"}},
+		{"port:5900-5901 country:DE", []string{"10.1.2.3", "10.1.2.30"}},
+		{"service:ssh|http", []string{"10.1.2.30", "192.0.2.10"}},
+		{"label:remote", []string{"10.1.2.3"}},
+		{"location:berlin", []string{"10.1.2.3"}},
+		{"asn:AS3320", []string{"10.1.2.3"}},
+		{"seen:>2026-09-15", []string{"10.1.2.30"}},
+		{"seen:2026-09-25 -port:80", nil},
+	}
+	for _, tt := range tests {
+		q, err := Parse(tt.expr)
+		if err != nil {
+			t.Errorf("%s: %v", tt.expr, err)
+			continue
+		}
+		found, err := q.FindAll()
+		if err != nil {
+			t.Errorf("%s: %v", tt.expr, err)
+			continue
+		}
+		var got []string
+		for _, h := range found {
+			got = append(got, h.IP)
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s matched %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package filter
+
+import (
+	"path/filepath"
+	"testing"
+
+	"smuggr.xyz/thughunter/common/models"
+	"smuggr.xyz/thughunter/core/audit"
+	"smuggr.xyz/thughunter/core/config"
+	"smuggr.xyz/thughunter/core/datastore"
+)
+
+func TestPurge(t *testing.T) {
+	dir := t.TempDir()
+	datastore.Initialize(filepath.Join(dir, "purge.db"))
+	audit.Initialize(config.Audit{LogPath: filepath.Join(dir, "audit.jsonl")})
+
+	for _, h := range []models.Host{
+		{IP: "192.0.2.1", Country: "DE", Services: models.JSONServiceMap{"VNC": 5900}},
+		{IP: "192.0.2.2", Country: "DE", Services: models.JSONServiceMap{"VNC": 5901}},
+		{IP: "198.51.100.1", Country: "FR", Services: models.JSONServiceMap{"VNC": 5900}},
+	} {
+		if err := datastore.DB.Create(&h).Error; err != nil {
+			t.Fatal(err)
+		}
+		datastore.DB.Create(&models.SourceRecord{IP: h.IP, Source: "dom"})
+		datastore.DB.Create(&models.HostChange{IP: h.IP, Field: "hostname", New: "x"})
+	}
+	datastore.DB.Create(&models.Finding{IP: "192.0.2.1", Port: 5900, State: "open"})
+
+	if _, err := (&Query{}).Purge(); err == nil {
+		t.Error("purge without a filter went through")
+	}
+
+	q, err := Parse("country:DE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := q.Purge()
+	if err != nil || n != 2 {
+		t.Fatalf("purged %d, %v, want 2", n, err)
+	}
+
+	for _, table := range []interface{}{&models.Host{}, &models.HostService{}, &models.SourceRecord{}, &models.HostChange{}} {
+		var ips []string
+		datastore.DB.Model(table).Distinct().Pluck("ip", &ips)
+		if len(ips) != 1 || ips[0] != "198.51.100.1" {
+			t.Errorf("%T left %v", table, ips)
+		}
+	}
+	var findings int64
+	datastore.DB.Model(&models.Finding{}).Count(&findings)
+	if findings != 1 {
+		t.Errorf("purge removed findings")
+	}
+
+	var entry models.AuditEntry
+	if err := datastore.DB.Where("action = ?", audit.ActionPurge).First(&entry).Error; err != nil {
+		t.Fatal("purge was not audited:", err)
+	}
+	if entry.Details["filter"] != "country:DE" || entry.Details["hosts"] != "2" {
+		t.Errorf("audited %v", entry.Details)
+	}
+}
@@ -0,0 +1,149 @@
+// core/findings/findings.go
+package findings
+
+import (
+	"fmt"
+	"time"
+
+	"smuggr.xyz/thughunter/common/models"
+	"smuggr.xyz/thughunter/core/datastore"
+)
+
+const (
+	StateOpen  = "open"
+	StateFixed = "fixed"
+
+	ChangeNew      = "new"
+	ChangeReopened = "reopened"
+	ChangeFixed    = "fixed"
+)
+
+// Outcome is what a scan showed about a target.
+type Outcome int
+
+const (
+	Inconclusive Outcome = iota // leaves the finding as it is
+	Exposed
+	Closed
+)
+
+type Target struct {
+	Host    models.Host
+	Port    int
+	Outcome Outcome
+}
+
+type Change struct {
+	Kind    string
+	Finding models.Finding
+}
+
+func Reconcile(scanned []Target, scan string, at time.Time) []Change {
+	var changes []Change
+
+	for _, t := range scanned {
+		if t.Outcome == Inconclusive {
+			continue
+		}
+		key := Key(t.Host.IP, t.Port)
+
+		var f models.Finding
+		r := datastore.DB.Where("ip = ? AND port = ?", t.Host.IP, t.Port).Limit(1).Find(&f)
+		if r.Error != nil {
+			fmt.Printf("[!] Failed to load finding %s: %v\n", key, r.Error)
+			continue
+		}
+		exists := r.RowsAffected > 0
+
+		if t.Outcome == Closed {
+			if exists && f.State == StateOpen {
+				f.State = StateFixed
+				f.FixedAt = &at
+				f.LastScan = scan
+				save(&f)
+				if f.Triage == TriageReported {
+					autoTriage(&f, TriageRemediated, "target no longer offers unauthenticated access")
+				}
+				changes = append(changes, Change{Kind: ChangeFixed, Finding: f})
+			}
+			continue
+		}
+
+		kind := ""
+		if !exists {
+			f = models.Finding{IP: t.Host.IP, Port: t.Port, FirstSeen: at, Triage: TriageNew}
+			kind = ChangeNew
+		} else if f.State == StateFixed {
+			kind = ChangeReopened
+		}
+		f.State = StateOpen
+		f.FixedAt = nil
+		f.Hostname = t.Host.Hostname
+		f.Location = t.Host.Location
+		f.LastSeen = at
+		f.LastScan = scan
+		save(&f)
+		if kind == ChangeReopened && f.Triage == TriageRemediated {
+			autoTriage(&f, TriageNeedsReview, "target offers unauthenticated access again")
+		}
+		if kind != "" {
+			changes = append(changes, Change{Kind: kind, Finding: f})
+		}
+	}
+
+	return changes
+}
+
+// Pending returns the changes no notification has announced yet. A finding
+// that was fixed before it was ever announced is left out.
+func Pending() ([]Change, error) {
+	var list []models.Finding
+	err := datastore.DB.Where("notified_state <> state AND NOT (state = ? AND notified_state = '')", StateFixed).
+		Order("id").Find(&list).Error
+	if err != nil {
+		return nil, err
+	}
+	changes := make([]Change, 0, len(list))
+	for _, f := range list {
+		kind := ChangeFixed
+		switch {
+		case f.State == StateOpen && f.NotifiedState == "":
+			kind = ChangeNew
+		case f.State == StateOpen:
+			kind = ChangeReopened
+		}
+		changes = append(changes, Change{Kind: kind, Finding: f})
+	}
+	return changes, nil
+}
+
+// MarkNotified records that changes were announced.
+func MarkNotified(changes []Change) {
+	for _, c := range changes {
+		err := datastore.DB.Model(&models.Finding{}).Where("id = ?", c.Finding.ID).
+			Update("notified_state", c.Finding.State).Error
+		if err != nil {
+			fmt.Printf("[!] Failed to mark %s as notified: %v\n", Key(c.Finding.IP, c.Finding.Port), err)
+		}
+	}
+}
+
+func Key(ip string, port int) string {
+	return fmt.Sprintf("%s:%d", ip, port)
+}
+
+func autoTriage(f *models.Finding, to, reason string) {
+	if err := SetTriage(f, to, "scanner"); err != nil {
+		fmt.Printf("[!] Failed to update triage for %s: %v\n", Key(f.IP, f.Port), err)
+		return
+	}
+	if err := AddNote(f, "scanner", reason); err != nil {
+		fmt.Printf("[!] Failed to add note to %s: %v\n", Key(f.IP, f.Port), err)
+	}
+}
+
+func save(f *models.Finding) {
+	if err := datastore.DB.Save(f).Error; err != nil {
+		fmt.Printf("[!] Failed to save finding %s: %v\n", Key(f.IP, f.Port), err)
+	}
+}
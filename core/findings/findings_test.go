@@ -0,0 +1,72 @@
+package findings
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"smuggr.xyz/thughunter/common/models"
+	"smuggr.xyz/thughunter/core/datastore"
+)
+
+func target(ip string, o Outcome) Target {
+	return Target{Host: models.Host{IP: ip}, Port: 5900, Outcome: o}
+}
+
+func pendingKinds(t *testing.T) map[string]string {
+	t.Helper()
+	pending, err := Pending()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := make(map[string]string)
+	for _, c := range pending {
+		out[c.Finding.IP] = c.Kind
+	}
+	return out
+}
+
+func TestPendingSurvivesScansThatDoNotNotify(t *testing.T) {
+	datastore.Initialize(filepath.Join(t.TempDir(), "findings.db"))
+	at := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	// A manual scan finds two hosts and notifies nobody.
+	Reconcile([]Target{target("192.0.2.1", Exposed), target("192.0.2.2", Exposed)}, "manual", at)
+	// The first is gone again before anyone was told about it.
+	Reconcile([]Target{target("192.0.2.1", Closed)}, "manual", at.Add(time.Hour))
+
+	// The scheduled scan sees the second again and must still announce it.
+	changes := Reconcile([]Target{target("192.0.2.2", Exposed)}, "scheduled", at.Add(2*time.Hour))
+	if len(changes) != 0 {
+		t.Fatalf("second sighting reported %v", changes)
+	}
+	got := pendingKinds(t)
+	if len(got) != 1 || got["192.0.2.2"] != ChangeNew {
+		t.Fatalf("pending %v, want 192.0.2.2 as new", got)
+	}
+
+	pending, _ := Pending()
+	MarkNotified(pending)
+	if got := pendingKinds(t); len(got) != 0 {
+		t.Fatalf("pending after notifying %v", got)
+	}
+
+	Reconcile([]Target{target("192.0.2.2", Closed)}, "manual", at.Add(3*time.Hour))
+	if got := pendingKinds(t); got["192.0.2.2"] != ChangeFixed {
+		t.Fatalf("pending %v, want 192.0.2.2 as fixed", got)
+	}
+	// Reopened before the fix was announced: nothing changed for whoever
+	// was told it was open.
+	Reconcile([]Target{target("192.0.2.2", Exposed)}, "manual", at.Add(4*time.Hour))
+	if got := pendingKinds(t); len(got) != 0 {
+		t.Fatalf("pending %v, want nothing", got)
+	}
+
+	Reconcile([]Target{target("192.0.2.2", Closed)}, "manual", at.Add(5*time.Hour))
+	pending, _ = Pending()
+	MarkNotified(pending)
+	Reconcile([]Target{target("192.0.2.2", Exposed), target("192.0.2.3", Inconclusive)}, "manual", at.Add(6*time.Hour))
+	if got := pendingKinds(t); len(got) != 1 || got["192.0.2.2"] != ChangeReopened {
+		t.Fatalf("pending %v, want 192.0.2.2 as reopened", got)
+	}
+}
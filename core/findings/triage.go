@@ -0,0 +1,180 @@
+// core/findings/triage.go
+package findings
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"smuggr.xyz/thughunter/common/models"
+	"smuggr.xyz/thughunter/core/datastore"
+)
+
+const (
+	TriageNew           = "new"
+	TriageNeedsReview   = "needs_review"
+	TriageConfirmed     = "confirmed"
+	TriageFalsePositive = "false_positive"
+	TriageReported      = "reported"
+	TriageRemediated    = "remediated"
+)
+
+var TriageStatuses = []string{
+	TriageNew,
+	TriageNeedsReview,
+	TriageConfirmed,
+	TriageFalsePositive,
+	TriageReported,
+	TriageRemediated,
+}
+
+var transitions = map[string][]string{
+	TriageNew:           {TriageNeedsReview, TriageConfirmed, TriageFalsePositive},
+	TriageNeedsReview:   {TriageConfirmed, TriageFalsePositive},
+	TriageConfirmed:     {TriageNeedsReview, TriageReported, TriageFalsePositive, TriageRemediated},
+	TriageFalsePositive: {TriageNeedsReview, TriageConfirmed},
+	TriageReported:      {TriageConfirmed, TriageRemediated},
+	TriageRemediated:    {TriageNeedsReview, TriageConfirmed},
+}
+
+var ErrNotFound = errors.New("finding not found")
+
+type ListOptions struct {
+	Triage   string
+	Assignee string
+	Tag      string
+}
+
+func CanTransition(from, to string) bool {
+	if from == "" {
+		from = TriageNew
+	}
+	for _, t := range transitions[from] {
+		if t == to {
+			return true
+		}
+	}
+	return false
+}
+
+func Lookup(target string) (models.Finding, error) {
+	var f models.Finding
+	i := strings.LastIndex(target, ":")
+	if i <= 0 {
+		return f, fmt.Errorf("invalid target %q, expected ip:port", target)
+	}
+	port, err := strconv.Atoi(target[i+1:])
+	if err != nil {
+		return f, fmt.Errorf("invalid port in %q", target)
+	}
+	ip := strings.Trim(target[:i], "[]")
+
+	r := datastore.DB.Preload("Notes").Where("ip = ? AND port = ?", ip, port).Limit(1).Find(&f)
+	if r.Error == nil && r.RowsAffected == 0 {
+		return f, fmt.Errorf("%w: %s", ErrNotFound, target)
+	}
+	return f, r.Error
+}
+
+func Get(id uint) (models.Finding, error) {
+	var f models.Finding
+	r := datastore.DB.Preload("Notes").Limit(1).Find(&f, id)
+	if r.Error == nil && r.RowsAffected == 0 {
+		return f, fmt.Errorf("%w: #%d", ErrNotFound, id)
+	}
+	return f, r.Error
+}
+
+func List(opts ListOptions) ([]models.Finding, error) {
+	tx := datastore.DB.Preload("Notes").Order("ip, port")
+	if opts.Triage != "" {
+		tx = tx.Where("triage = ?", opts.Triage)
+	}
+	if opts.Assignee != "" {
+		tx = tx.Where("assignee = ?", opts.Assignee)
+	}
+	var out []models.Finding
+	if err := tx.Find(&out).Error; err != nil {
+		return nil, err
+	}
+	if opts.Tag == "" {
+		return out, nil
+	}
+	var tagged []models.Finding
+	for _, f := range out {
+		if hasTag(f.Tags, opts.Tag) {
+			tagged = append(tagged, f)
+		}
+	}
+	return tagged, nil
+}
+
+func SetTriage(f *models.Finding, to, author string) error {
+	from := f.Triage
+	if from == "" {
+		from = TriageNew
+	}
+	if from == to {
+		return nil
+	}
+	if !CanTransition(from, to) {
+		return fmt.Errorf("cannot move %s from %s to %s", Key(f.IP, f.Port), from, to)
+	}
+	f.Triage = to
+	if err := datastore.DB.Model(f).Update("triage", to).Error; err != nil {
+		return err
+	}
+	return AddNote(f, author, fmt.Sprintf("status %s → %s", from, to))
+}
+
+func Assign(f *models.Finding, assignee, author string) error {
+	f.Assignee = assignee
+	if err := datastore.DB.Model(f).Update("assignee", assignee).Error; err != nil {
+		return err
+	}
+	if assignee == "" {
+		return AddNote(f, author, "unassigned")
+	}
+	return AddNote(f, author, "assigned to "+assignee)
+}
+
+func AddNote(f *models.Finding, author, text string) error {
+	n := models.FindingNote{FindingID: f.ID, Author: author, Text: text, CreatedAt: time.Now()}
+	if err := datastore.DB.Create(&n).Error; err != nil {
+		return err
+	}
+	f.Notes = append(f.Notes, n)
+	return nil
+}
+
+func UpdateTags(f *models.Finding, add, remove []string) error {
+	tags := models.JSONStringSlice{}
+	for _, t := range f.Tags {
+		if !hasTag(remove, t) {
+			tags = append(tags, t)
+		}
+	}
+	for _, t := range add {
+		if t != "" && !hasTag(tags, t) {
+			tags = append(tags, t)
+		}
+	}
+	f.Tags = tags
+	return datastore.DB.Model(f).Update("tags", tags).Error
+}
+
+func SetTags(f *models.Finding, tags []string) error {
+	f.Tags = nil
+	return UpdateTags(f, tags, nil)
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
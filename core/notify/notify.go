@@ -0,0 +1,198 @@
+// core/notify/notify.go
+package notify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"text/template"
+	"time"
+
+	"smuggr.xyz/thughunter/core/config"
+	"smuggr.xyz/thughunter/core/findings"
+)
+
+const defaultTemplate = `ThugHunter {{.Run}}: {{len .New}} new, {{len .Fixed}} fixed
+{{range .New}}- NEW {{.IP}}:{{.Port}}{{if .Hostname}} ({{.Hostname}}){{end}}{{if .Location}} {{.Location}}{{end}}
+{{end}}{{range .Fixed}}- FIXED {{.IP}}:{{.Port}}{{if .Hostname}} ({{.Hostname}}){{end}}
+{{end}}`
+
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, b Batch, text string) error
+}
+
+type Batch struct {
+	Run     string
+	Time    time.Time
+	Changes []findings.Change
+}
+
+type Retry struct {
+	Attempts int
+	Backoff  time.Duration
+}
+
+type templateData struct {
+	Run   string
+	Time  time.Time
+	New   []findingView
+	Fixed []findingView
+}
+
+type findingView struct {
+	IP       string
+	Port     int
+	Hostname string
+	Location string
+	Kind     string
+}
+
+var cfg config.Notify
+
+// Send reports whether any notifier delivered the batch.
+func Send(b Batch) bool {
+	if len(b.Changes) == 0 {
+		return true
+	}
+	notifiers := FromConfig(cfg)
+	if len(notifiers) == 0 {
+		return false
+	}
+
+	tmpl, err := loadTemplate()
+	if err != nil {
+		fmt.Printf("[!] Notification template error: %v\n", err)
+		return false
+	}
+	text, err := Render(tmpl, b)
+	if err != nil {
+		fmt.Printf("[!] Failed to render notification: %v\n", err)
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	sent := false
+	for _, n := range notifiers {
+		if err := n.Notify(ctx, b, text); err != nil {
+			fmt.Printf("[!] %s notification failed: %v\n", n.Name(), err)
+			continue
+		}
+		sent = true
+		fmt.Printf("🔔 Sent %d changes to %s\n", len(b.Changes), n.Name())
+	}
+	return sent
+}
+
+func Configure(c config.Notify) {
+	cfg = c
+}
+
+func FromConfig(c config.Notify) []Notifier {
+	retry := Retry{Attempts: c.Retries, Backoff: 2 * time.Second}
+	var out []Notifier
+	if c.WebhookURL != "" {
+		out = append(out, &Webhook{URL: c.WebhookURL, Retry: retry})
+	}
+	if c.SlackURL != "" {
+		out = append(out, &Slack{URL: c.SlackURL, Retry: retry})
+	}
+	if c.TeamsURL != "" {
+		out = append(out, &Teams{URL: c.TeamsURL, Retry: retry})
+	}
+	return out
+}
+
+func loadTemplate() (*template.Template, error) {
+	src := defaultTemplate
+	if path := cfg.TemplatePath; path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		src = string(data)
+	}
+	return template.New("notification").Parse(src)
+}
+
+func Render(tmpl *template.Template, b Batch) (string, error) {
+	data := templateData{Run: b.Run, Time: b.Time}
+	for _, c := range b.Changes {
+		v := findingView{
+			IP:       c.Finding.IP,
+			Port:     c.Finding.Port,
+			Hostname: c.Finding.Hostname,
+			Location: c.Finding.Location,
+			Kind:     c.Kind,
+		}
+		if c.Kind == findings.ChangeFixed {
+			data.Fixed = append(data.Fixed, v)
+		} else {
+			data.New = append(data.New, v)
+		}
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func post(ctx context.Context, url string, body []byte, retry Retry) error {
+	backoff := retry.Backoff
+	var lastErr error
+	for attempt := 1; attempt <= retry.Attempts; attempt++ {
+		lastErr = postOnce(ctx, url, body)
+		if lastErr == nil {
+			return nil
+		}
+		var perm permanentError
+		if errors.As(lastErr, &perm) || attempt == retry.Attempts {
+			break
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff *= 2
+	}
+	return lastErr
+}
+
+type permanentError struct {
+	msg string
+}
+
+func (e permanentError) Error() string {
+	return e.msg
+}
+
+func postOnce(ctx context.Context, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return permanentError{msg: err.Error()}
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	default:
+		return permanentError{msg: fmt.Sprintf("rejected with HTTP %d", resp.StatusCode)}
+	}
+}
@@ -0,0 +1,156 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"smuggr.xyz/thughunter/common/models"
+	"smuggr.xyz/thughunter/core/findings"
+)
+
+// standIn records the bodies it receives and answers with the given
+// statuses in turn, repeating the last one.
+type standIn struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	statuses []int
+	bodies   [][]byte
+}
+
+func newStandIn(t *testing.T, statuses ...int) *standIn {
+	s := &standIn{statuses: statuses}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("got %s with Content-Type %q", r.Method, r.Header.Get("Content-Type"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		s.bodies = append(s.bodies, body)
+		status := s.statuses[0]
+		if len(s.statuses) > 1 {
+			s.statuses = s.statuses[1:]
+		}
+		s.mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *standIn) requests() [][]byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.bodies
+}
+
+var (
+	testRetry = Retry{Attempts: 3, Backoff: time.Millisecond}
+	testBatch = Batch{
+		Run:  "scan 2026-10-19",
+		Time: time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC),
+		Changes: []findings.Change{
+			{Kind: findings.ChangeNew, Finding: models.Finding{IP: "198.51.100.7", Port: 5900, Hostname: "vnc.example.net"}},
+			{Kind: findings.ChangeFixed, Finding: models.Finding{IP: "2001:db8::1", Port: 5901}},
+		},
+	}
+)
+
+func TestPayloads(t *testing.T) {
+	tests := []struct {
+		name  string
+		make  func(url string) Notifier
+		check func(t *testing.T, p map[string]interface{})
+	}{
+		{
+			name: "webhook",
+			make: func(url string) Notifier { return &Webhook{URL: url, Retry: testRetry} },
+			check: func(t *testing.T, p map[string]interface{}) {
+				if p["run"] != testBatch.Run || p["text"] != "hello" {
+					t.Errorf("run/text = %v/%v", p["run"], p["text"])
+				}
+				events, _ := p["events"].([]interface{})
+				if len(events) != 2 {
+					t.Fatalf("%d events, want 2", len(events))
+				}
+				first := events[0].(map[string]interface{})
+				if first["kind"] != "new" || first["ip"] != "198.51.100.7" || first["port"] != 5900.0 || first["hostname"] != "vnc.example.net" {
+					t.Errorf("first event = %v", first)
+				}
+				second := events[1].(map[string]interface{})
+				if _, ok := second["hostname"]; ok || second["kind"] != "fixed" {
+					t.Errorf("second event = %v", second)
+				}
+			},
+		},
+		{
+			name: "slack",
+			make: func(url string) Notifier { return &Slack{URL: url, Retry: testRetry} },
+			check: func(t *testing.T, p map[string]interface{}) {
+				if len(p) != 1 || p["text"] != "hello" {
+					t.Errorf("payload = %v", p)
+				}
+			},
+		},
+		{
+			name: "teams",
+			make: func(url string) Notifier { return &Teams{URL: url, Retry: testRetry} },
+			check: func(t *testing.T, p map[string]interface{}) {
+				if p["@type"] != "MessageCard" || p["text"] != "hello" || p["title"] != "ThugHunter "+testBatch.Run {
+					t.Errorf("payload = %v", p)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newStandIn(t, http.StatusOK)
+			if err := tt.make(srv.URL).Notify(context.Background(), testBatch, "hello"); err != nil {
+				t.Fatal(err)
+			}
+			reqs := srv.requests()
+			if len(reqs) != 1 {
+				t.Fatalf("%d requests, want 1", len(reqs))
+			}
+			var p map[string]interface{}
+			if err := json.Unmarshal(reqs[0], &p); err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, p)
+		})
+	}
+}
+
+func TestPostRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		wantErr  bool
+		wantReqs int
+	}{
+		{"ok", []int{http.StatusNoContent}, false, 1},
+		{"5xx then ok", []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusOK}, false, 3},
+		{"429 then ok", []int{http.StatusTooManyRequests, http.StatusOK}, false, 2},
+		{"5xx until out of attempts", []int{http.StatusInternalServerError}, true, 3},
+		{"4xx is not retried", []int{http.StatusBadRequest}, true, 1},
+		{"404 is not retried", []int{http.StatusNotFound, http.StatusOK}, true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newStandIn(t, tt.statuses...)
+			err := post(context.Background(), srv.URL, []byte(`{}`), testRetry)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if n := len(srv.requests()); n != tt.wantReqs {
+				t.Errorf("%d requests, want %d", n, tt.wantReqs)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+// core/notify/payloads.go
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+type Webhook struct {
+	URL   string
+	Retry Retry
+}
+
+type webhookPayload struct {
+	Run    string         `json:"run"`
+	Time   time.Time      `json:"time"`
+	Text   string         `json:"text"`
+	Events []webhookEvent `json:"events"`
+}
+
+type webhookEvent struct {
+	Kind      string    `json:"kind"`
+	IP        string    `json:"ip"`
+	Port      int       `json:"port"`
+	Hostname  string    `json:"hostname,omitempty"`
+	Location  string    `json:"location,omitempty"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+func (w *Webhook) Name() string { return "webhook" }
+
+func (w *Webhook) Notify(ctx context.Context, b Batch, text string) error {
+	p := webhookPayload{Run: b.Run, Time: b.Time, Text: text}
+	for _, c := range b.Changes {
+		p.Events = append(p.Events, webhookEvent{
+			Kind:      c.Kind,
+			IP:        c.Finding.IP,
+			Port:      c.Finding.Port,
+			Hostname:  c.Finding.Hostname,
+			Location:  c.Finding.Location,
+			FirstSeen: c.Finding.FirstSeen,
+			LastSeen:  c.Finding.LastSeen,
+		})
+	}
+	body, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return post(ctx, w.URL, body, w.Retry)
+}
+
+type Slack struct {
+	URL   string
+	Retry Retry
+}
+
+func (s *Slack) Name() string { return "slack" }
+
+func (s *Slack) Notify(ctx context.Context, b Batch, text string) error {
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+	return post(ctx, s.URL, body, s.Retry)
+}
+
+type Teams struct {
+	URL   string
+	Retry Retry
+}
+
+func (t *Teams) Name() string { return "teams" }
+
+func (t *Teams) Notify(ctx context.Context, b Batch, text string) error {
+	body, err := json.Marshal(map[string]string{
+		"@type":    "MessageCard",
+		"@context": "https://schema.org/extensions",
+		"summary":  "ThugHunter " + b.Run,
+		"title":    "ThugHunter " + b.Run,
+		"text":     text,
+	})
+	if err != nil {
+		return err
+	}
+	return post(ctx, t.URL, body, t.Retry)
+}
@@ -0,0 +1,323 @@
+// core/queries/queries.go
+package queries
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"smuggr.xyz/thughunter/common/models"
+	"smuggr.xyz/thughunter/core/audit"
+	"smuggr.xyz/thughunter/core/datastore"
+	"smuggr.xyz/thughunter/core/scraper"
+)
+
+const exportVersion = 1
+
+var defaults = []models.SavedQuery{
+	{Name: "noauth-linux", Query: `host.services.vnc.security_types.value = "1" and host.operating_system.product = "linux"`, Tags: models.JSONStringSlice{"vnc", "linux"}},
+	{Name: "noauth-qemu", Query: `host.services.vnc.security_types.value = "1" and host.services.vnc.desktop_name= "QEMU"`, Tags: models.JSONStringSlice{"vnc", "qemu"}},
+	{Name: "noauth-none-type", Query: `host.services.vnc.security_types.value = "1" and host.services.vnc.security_types.name = "None"`, Tags: models.JSONStringSlice{"vnc"}},
+	{Name: "noauth-linux-unix", Query: `host.services.vnc.security_types.value = "1" and (host.operating_system.product = "linux" or host.operating_system.product = "unix")`, Tags: models.JSONStringSlice{"vnc", "linux", "unix"}},
+	{Name: "none-security-type", Query: `host.services.vnc.security_types.name = "None"`, Tags: models.JSONStringSlice{"vnc"}},
+}
+
+var ErrNotFound = errors.New("saved query not found")
+
+type exportFile struct {
+	Version int           `yaml:"version"`
+	Queries []exportEntry `yaml:"queries"`
+}
+
+type exportEntry struct {
+	Name  string   `yaml:"name"`
+	Query string   `yaml:"query"`
+	Tags  []string `yaml:"tags,omitempty"`
+	Notes string   `yaml:"notes,omitempty"`
+}
+
+type Stats struct {
+	Runs         int
+	TotalNew     int
+	TotalUpdated int
+	LastNewAt    *time.Time
+}
+
+const seededMarker = "queries.seeded"
+
+// SeedDefaults adds the default queries once per database.
+func SeedDefaults() {
+	var seeded int64
+	datastore.DB.Model(&models.Marker{}).Where("name = ?", seededMarker).Count(&seeded)
+	if seeded > 0 {
+		return
+	}
+	var count int64
+	datastore.DB.Model(&models.SavedQuery{}).Count(&count)
+	defer func() {
+		if err := datastore.DB.Create(&models.Marker{Name: seededMarker, SetAt: time.Now()}).Error; err != nil {
+			fmt.Printf("[!] Failed to record query seeding: %v\n", err)
+		}
+	}()
+	if count > 0 {
+		return
+	}
+	for _, q := range defaults {
+		q := q
+		if err := datastore.DB.Create(&q).Error; err != nil {
+			fmt.Printf("[!] Failed to seed query %s: %v\n", q.Name, err)
+		}
+	}
+	fmt.Printf("Seeded query library with %d default queries\n", len(defaults))
+}
+
+func List() ([]models.SavedQuery, error) {
+	var qs []models.SavedQuery
+	err := datastore.DB.Order("name").Find(&qs).Error
+	return qs, err
+}
+
+func Get(name string) (models.SavedQuery, error) {
+	var q models.SavedQuery
+	r := datastore.DB.Where("name = ?", name).Limit(1).Find(&q)
+	if r.Error == nil && r.RowsAffected == 0 {
+		return q, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return q, r.Error
+}
+
+func Add(q models.SavedQuery) (models.SavedQuery, error) {
+	if err := validate(q); err != nil {
+		return q, err
+	}
+	if _, err := Get(q.Name); err == nil {
+		return q, fmt.Errorf("a query named %q already exists", q.Name)
+	}
+	err := datastore.DB.Create(&q).Error
+	return q, err
+}
+
+func Update(q models.SavedQuery) error {
+	if err := validate(q); err != nil {
+		return err
+	}
+	return datastore.DB.Save(&q).Error
+}
+
+func Delete(name string) error {
+	q, err := Get(name)
+	if err != nil {
+		return err
+	}
+	if err := datastore.DB.Delete(&q).Error; err != nil {
+		return err
+	}
+	audit.Record(audit.ActionQueryDelete, map[string]string{"name": q.Name, "query": q.Query})
+	return nil
+}
+
+func RecordRun(q *models.SavedQuery, query string, started time.Time, st scraper.Stats, runErr error) {
+	run := models.QueryRun{
+		Query:      query,
+		StartedAt:  started,
+		FinishedAt: time.Now(),
+		New:        st.New,
+		Updated:    st.Updated,
+		Pages:      st.Pages,
+		Collected:  st.Collected,
+		Available:  st.Available,
+		Credits:    st.Credits,
+		StoppedBy:  st.StoppedBy,
+		Cached:     st.CachedAt != nil,
+	}
+	if runErr != nil {
+		run.Error = runErr.Error()
+	}
+	if q != nil {
+		run.SavedQueryID = &q.ID
+		q.LastRunAt = &run.FinishedAt
+		q.LastNew = st.New
+		q.LastUpdated = st.Updated
+		if err := datastore.DB.Save(q).Error; err != nil {
+			fmt.Printf("[!] Failed to update query %s: %v\n", q.Name, err)
+		}
+	}
+	if err := datastore.DB.Create(&run).Error; err != nil {
+		fmt.Printf("[!] Failed to record query run: %v\n", err)
+	}
+}
+
+// Run runs a saved query in s and records the run.
+func Run(s *scraper.Session, q *models.SavedQuery, refresh bool) (scraper.Stats, error) {
+	started := time.Now()
+	st, err := s.Run(q.Query, refresh)
+	RecordRun(q, q.Query, started, st, err)
+	return st, err
+}
+
+func Select(spec string) ([]models.SavedQuery, error) {
+	all, err := List()
+	if err != nil {
+		return nil, err
+	}
+	spec = strings.TrimSpace(spec)
+	if spec == "" || spec == "all" {
+		return all, nil
+	}
+
+	var selected []models.SavedQuery
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if tag, ok := strings.CutPrefix(item, "tag:"); ok {
+			for _, q := range all {
+				if hasTag(q, tag) && !containsQuery(selected, q.ID) {
+					selected = append(selected, q)
+				}
+			}
+			continue
+		}
+		q, err := Get(item)
+		if err != nil {
+			return nil, err
+		}
+		if !containsQuery(selected, q.ID) {
+			selected = append(selected, q)
+		}
+	}
+	return selected, nil
+}
+
+func hasTag(q models.SavedQuery, tag string) bool {
+	for _, t := range q.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsQuery(qs []models.SavedQuery, id uint) bool {
+	for _, q := range qs {
+		if q.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func History(q models.SavedQuery) ([]models.QueryRun, error) {
+	var runs []models.QueryRun
+	err := datastore.DB.Where("saved_query_id = ?", q.ID).Order("started_at desc").Find(&runs).Error
+	return runs, err
+}
+
+func StatsFor(q models.SavedQuery) (Stats, error) {
+	var st Stats
+	runs, err := History(q)
+	if err != nil {
+		return st, err
+	}
+	st.Runs = len(runs)
+	for _, r := range runs {
+		st.TotalNew += r.New
+		st.TotalUpdated += r.Updated
+		if r.New > 0 && st.LastNewAt == nil {
+			t := r.FinishedAt
+			st.LastNewAt = &t
+		}
+	}
+	return st, nil
+}
+
+func Export(w io.Writer) error {
+	qs, err := List()
+	if err != nil {
+		return err
+	}
+	out := exportFile{Version: exportVersion}
+	for _, q := range qs {
+		out.Queries = append(out.Queries, exportEntry{
+			Name:  q.Name,
+			Query: q.Query,
+			Tags:  q.Tags,
+			Notes: q.Notes,
+		})
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	defer enc.Close()
+	return enc.Encode(out)
+}
+
+func Import(r io.Reader) (added, updated int, err error) {
+	var in exportFile
+	if err := yaml.NewDecoder(r).Decode(&in); err != nil {
+		return 0, 0, fmt.Errorf("decode yaml: %w", err)
+	}
+	if in.Version > exportVersion {
+		return 0, 0, fmt.Errorf("unsupported query file version %d", in.Version)
+	}
+
+	var names []string
+	defer func() {
+		details := map[string]string{
+			"added":   strconv.Itoa(added),
+			"updated": strconv.Itoa(updated),
+			"names":   strings.Join(names, ","),
+		}
+		if err != nil {
+			details["error"] = err.Error()
+		}
+		audit.Record(audit.ActionQueryImport, details)
+	}()
+
+	for _, e := range in.Queries {
+		names = append(names, e.Name)
+		q, err := Get(e.Name)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return added, updated, err
+		}
+		isNew := err != nil
+		q.Name = e.Name
+		q.Query = e.Query
+		q.Tags = e.Tags
+		q.Notes = e.Notes
+		if isNew {
+			if _, err := Add(q); err != nil {
+				return added, updated, fmt.Errorf("%s: %w", e.Name, err)
+			}
+			added++
+			continue
+		}
+		if err := Update(q); err != nil {
+			return added, updated, fmt.Errorf("%s: %w", e.Name, err)
+		}
+		updated++
+	}
+	return added, updated, nil
+}
+
+func ParseTags(s string) models.JSONStringSlice {
+	var tags models.JSONStringSlice
+	for _, t := range strings.Split(s, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+func validate(q models.SavedQuery) error {
+	if strings.TrimSpace(q.Name) == "" {
+		return errors.New("query name must not be empty")
+	}
+	if strings.TrimSpace(q.Query) == "" {
+		return errors.New("query text must not be empty")
+	}
+	return nil
+}
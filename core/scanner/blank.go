@@ -0,0 +1,116 @@
+// core/scanner/blank.go
+package scanner
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// blankTolerance is how far, in 16-bit colour units, a pixel may be from
+// the first one and still count as the same colour.
+const blankTolerance = 0x0100
+
+func isSingleColorImage(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		fmt.Printf("[!] Failed to stat image: %v\n", err)
+		return false
+	}
+	if info.Size() < 1024 {
+		fmt.Printf("[!] Image too small or empty: %s\n", path)
+		return false
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Printf("[!] Failed to read image: %v\n", err)
+		return false
+	}
+
+	contentType := http.DetectContentType(data[:512])
+	if !strings.HasPrefix(contentType, "image/png") {
+		fmt.Printf("[!] Invalid image type (%s): %s\n", contentType, path)
+		return false
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		fmt.Printf("[!] Failed to decode image: %v\n", err)
+		return false
+	}
+	return singleColor(img)
+}
+
+// singleColor reports whether every pixel of img has the colour of the
+// first one. RGBA and NRGBA images, which is what PNG snapshots decode to,
+// are read straight from their pixel buffers.
+func singleColor(img image.Image) bool {
+	switch m := img.(type) {
+	case *image.RGBA:
+		return singleColorPix(m.Pix, m.Stride, m.Rect, rgbaPixel)
+	case *image.NRGBA:
+		return singleColorPix(m.Pix, m.Stride, m.Rect, nrgbaPixel)
+	}
+
+	bounds := img.Bounds()
+	r0, g0, b0, _ := img.At(bounds.Min.X, bounds.Min.Y).RGBA()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			if !sameColor(r0, g0, b0, r, g, b) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// singleColorPix walks a 4-bytes-per-pixel buffer row by row. pixel turns
+// the bytes of one pixel into 16-bit premultiplied red, green and blue, the
+// same values color.Color.RGBA returns.
+func singleColorPix(pix []byte, stride int, rect image.Rectangle, pixel func([]byte) (r, g, b uint32)) bool {
+	if rect.Empty() {
+		return true
+	}
+	width := rect.Dx() * 4
+	r0, g0, b0 := pixel(pix[:4])
+	for y := 0; y < rect.Dy(); y++ {
+		row := pix[y*stride : y*stride+width]
+		for i := 0; i < width; i += 4 {
+			r, g, b := pixel(row[i : i+4])
+			if !sameColor(r0, g0, b0, r, g, b) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func rgbaPixel(p []byte) (r, g, b uint32) {
+	r, g, b = uint32(p[0]), uint32(p[1]), uint32(p[2])
+	return r | r<<8, g | g<<8, b | b<<8
+}
+
+func nrgbaPixel(p []byte) (r, g, b uint32) {
+	r, g, b = uint32(p[0]), uint32(p[1]), uint32(p[2])
+	a := uint32(p[3]) * 0x101
+	r = (r | r<<8) * a / 0xffff
+	g = (g | g<<8) * a / 0xffff
+	b = (b | b<<8) * a / 0xffff
+	return r, g, b
+}
+
+func sameColor(r0, g0, b0, r, g, b uint32) bool {
+	return absDiff(r0, r) <= blankTolerance && absDiff(g0, g) <= blankTolerance && absDiff(b0, b) <= blankTolerance
+}
+
+func absDiff(a, b uint32) uint32 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
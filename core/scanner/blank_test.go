@@ -0,0 +1,70 @@
+package scanner
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// generic hides the concrete type, so singleColor takes the At() fallback.
+type generic struct {
+	image.Image
+}
+
+func fill(img interface {
+	image.Image
+	Set(x, y int, c color.Color)
+}, c color.Color) {
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			img.Set(x, y, c)
+		}
+	}
+}
+
+func testImages() map[string]image.Image {
+	rect := image.Rect(0, 0, 1024, 768)
+	blank := image.NewRGBA(rect)
+	fill(blank, color.RGBA{0x20, 0x40, 0x60, 0xff})
+	nblank := image.NewNRGBA(rect)
+	fill(nblank, color.NRGBA{0x20, 0x40, 0x60, 0x80})
+	noisy := image.NewRGBA(rect)
+	fill(noisy, color.RGBA{0x20, 0x40, 0x60, 0xff})
+	noisy.Set(1023, 767, color.RGBA{0xff, 0, 0, 0xff})
+	nearly := image.NewNRGBA(rect)
+	fill(nearly, color.NRGBA{0x20, 0x40, 0x60, 0xff})
+	nearly.Set(500, 400, color.NRGBA{0x21, 0x40, 0x60, 0xff})
+	sub := noisy.SubImage(image.Rect(0, 0, 512, 384))
+	return map[string]image.Image{"rgba": blank, "nrgba": nblank, "noisy": noisy, "nearly": nearly, "sub": sub}
+}
+
+func TestSingleColorFastPathMatchesAt(t *testing.T) {
+	want := map[string]bool{"rgba": true, "nrgba": true, "noisy": false, "nearly": false, "sub": true}
+	for name, img := range testImages() {
+		fast, slow := singleColor(img), singleColor(generic{img})
+		if fast != slow {
+			t.Errorf("%s: fast path %v, At() %v", name, fast, slow)
+		}
+		if fast != want[name] {
+			t.Errorf("%s: single colour %v, want %v", name, fast, want[name])
+		}
+	}
+}
+
+func BenchmarkSingleColor(b *testing.B) {
+	imgs := testImages()
+	for _, name := range []string{"rgba", "nrgba"} {
+		img := imgs[name]
+		b.Run(name+"/pix", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				singleColor(img)
+			}
+		})
+		b.Run(name+"/at", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				singleColor(generic{img})
+			}
+		})
+	}
+}
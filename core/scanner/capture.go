@@ -0,0 +1,109 @@
+// core/scanner/capture.go
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Target is one VNC service to snapshot.
+type Target struct {
+	IP   string
+	Port int
+}
+
+// String formats t for vncsnapshot: [v6]::port or ip::port.
+func (t Target) String() string {
+	return fmt.Sprintf("%s::%d", bracketIP(t.IP), t.Port)
+}
+
+// Addr formats t as host:port.
+func (t Target) Addr() string {
+	return net.JoinHostPort(t.IP, strconv.Itoa(t.Port))
+}
+
+func bracketIP(ip string) string {
+	if strings.Contains(ip, ":") {
+		return "[" + ip + "]"
+	}
+	return ip
+}
+
+// Capturer writes a PNG snapshot of t to output.
+type Capturer interface {
+	Capture(ctx context.Context, t Target, output string) error
+}
+
+// FailureKind says why a capture failed.
+type FailureKind string
+
+const (
+	FailTimeout      FailureKind = "timeout"
+	FailRefused      FailureKind = "connection refused"
+	FailAuthRequired FailureKind = "authentication required"
+	FailSecurityType FailureKind = "unsupported security type"
+	FailProtocol     FailureKind = "protocol error"
+	FailBlank        FailureKind = "blank"
+	FailUnknown      FailureKind = "error"
+)
+
+// CaptureError is a failed capture of one target.
+type CaptureError struct {
+	Target Target
+	Kind   FailureKind
+	Detail string
+	Err    error
+}
+
+func (e *CaptureError) Error() string {
+	msg := fmt.Sprintf("%s: %s", e.Target, e.Kind)
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	} else if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *CaptureError) Unwrap() error {
+	return e.Err
+}
+
+// asCaptureError wraps err, treating an expired ctx as a timeout.
+func asCaptureError(ctx context.Context, t Target, err error) *CaptureError {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return &CaptureError{Target: t, Kind: FailTimeout, Err: ctx.Err()}
+	}
+	var ce *CaptureError
+	if errors.As(err, &ce) {
+		return ce
+	}
+	return &CaptureError{Target: t, Kind: FailUnknown, Err: err}
+}
+
+// VNCSnapshot captures with the vncsnapshot binary.
+type VNCSnapshot struct{}
+
+func (VNCSnapshot) Capture(ctx context.Context, t Target, output string) error {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "vncsnapshot", "-quiet", "-ignoreblank", t.String(), output)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return &CaptureError{Target: t, Kind: classify(stderr.String()), Detail: lastLine(stderr.String()), Err: err}
+	}
+	return nil
+}
+
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
@@ -0,0 +1,13 @@
+package scanner
+
+import "smuggr.xyz/thughunter/common/models"
+
+// PerformParallelSnapshots runs the pipeline over hosts for external tests.
+func PerformParallelSnapshots(c Capturer, snapshotDir, discardedDir string, hosts []models.Host) (scanOutcome, error) {
+	return performParallelSnapshots(c, snapshotDir, discardedDir, func(emit func(models.Host)) error {
+		for _, h := range hosts {
+			emit(h)
+		}
+		return nil
+	})
+}
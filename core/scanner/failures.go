@@ -0,0 +1,84 @@
+// core/scanner/failures.go
+package scanner
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Statuses of a stored ScanResult.
+const (
+	ResultWorking   = "working"
+	ResultFailed    = "failed"
+	ResultDiscarded = "discarded"
+)
+
+// Failure is a target that did not yield a usable snapshot.
+type Failure struct {
+	IP       string
+	Port     int
+	Kind     FailureKind
+	Detail   string
+	Attempts int
+}
+
+// failurePatterns are checked in order, most specific first.
+var failurePatterns = []struct {
+	re   *regexp.Regexp
+	kind FailureKind
+}{
+	{regexp.MustCompile(`(?i)unknown authentication scheme|security type|no supported|auth(entication)? (method|type).*not supported`), FailSecurityType},
+	{regexp.MustCompile(`(?i)authentication (failed|required)|password|vnc auth`), FailAuthRequired},
+	{regexp.MustCompile(`(?i)connection refused|unable to connect|couldn'?t connect|no route to host|network is unreachable`), FailRefused},
+	{regexp.MustCompile(`(?i)timed? ?out`), FailTimeout},
+	{regexp.MustCompile(`(?i)protocol|not a valid vnc|rfb|server closed connection|unexpected|bad (message|response)`), FailProtocol},
+}
+
+// classify reads what kind of failure vncsnapshot reported on stderr.
+func classify(stderr string) FailureKind {
+	for _, p := range failurePatterns {
+		if p.re.MatchString(stderr) {
+			return p.kind
+		}
+	}
+	return FailUnknown
+}
+
+// CategoryCount is how many targets failed with one kind.
+type CategoryCount struct {
+	Kind  FailureKind
+	Count int
+}
+
+// Breakdown counts failures by kind, most frequent first.
+func (s ScanSummary) Breakdown() []CategoryCount {
+	counts := make(map[FailureKind]int)
+	for _, f := range s.Failed {
+		counts[f.Kind]++
+	}
+	if s.Discarded > 0 {
+		counts[FailBlank] += s.Discarded
+	}
+	out := make([]CategoryCount, 0, len(counts))
+	for k, n := range counts {
+		out = append(out, CategoryCount{Kind: k, Count: n})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Kind < out[j].Kind
+	})
+	return out
+}
+
+// FormatBreakdown renders a breakdown as "timeout 3, connection refused 1".
+func FormatBreakdown(b []CategoryCount) string {
+	parts := make([]string, len(b))
+	for i, c := range b {
+		parts[i] = fmt.Sprintf("%s %d", c.Kind, c.Count)
+	}
+	return strings.Join(parts, ", ")
+}
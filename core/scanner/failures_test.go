@@ -0,0 +1,40 @@
+package scanner
+
+import "testing"
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		stderr string
+		want   FailureKind
+	}{
+		{"Connected to VNC server\nUnknown authentication scheme from VNC server: 19\n", FailSecurityType},
+		{"No supported security type\n", FailSecurityType},
+		{"Connected to VNC server\nVNC authentication failed\n", FailAuthRequired},
+		{"Password:\n", FailAuthRequired},
+		{"vncsnapshot: unable to connect: Connection refused\n", FailRefused},
+		{"connect: No route to host\n", FailRefused},
+		{"read: Connection timed out\n", FailTimeout},
+		{"Connected to VNC server\nVNC server closed connection\n", FailProtocol},
+		{"Not a valid VNC server\n", FailProtocol},
+		{"segmentation fault\n", FailUnknown},
+		{"", FailUnknown},
+	}
+	for _, tt := range tests {
+		if got := classify(tt.stderr); got != tt.want {
+			t.Errorf("classify(%q) = %q, want %q", tt.stderr, got, tt.want)
+		}
+	}
+}
+
+func TestParseRetryOn(t *testing.T) {
+	on, err := parseRetryOn(" Timeout, connection refused ,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !on[FailTimeout] || !on[FailRefused] || len(on) != 2 {
+		t.Errorf("got %v", on)
+	}
+	if _, err := parseRetryOn("timeout,bogus"); err == nil {
+		t.Error("unknown category accepted")
+	}
+}
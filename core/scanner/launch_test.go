@@ -0,0 +1,37 @@
+package scanner
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestLaunchCommand(t *testing.T) {
+	// A file named "2" would match [2001:db8::1] as an unquoted glob.
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "2"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		template, ip, port string
+		want               string
+	}{
+		{"printf '%%s|%%s' %s %s", "192.0.2.1", "5900", "192.0.2.1|5900"},
+		{"printf '%%s|%%s' %s %s", "2001:db8::1", "5901", "[2001:db8::1]|5901"},
+		{"printf '%%s' vnc://%s:%s", "2001:db8::1", "5900", "vnc://[2001:db8::1]:5900"},
+		{"printf '%%s|%%s' %s %s", "x'; echo injected; '", "$(id)", "x'; echo injected; '|$(id)"},
+	}
+	for _, tt := range tests {
+		cmd := exec.Command("sh", "-c", launchCommand(tt.template, tt.ip, tt.port))
+		cmd.Dir = dir
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("%s: %v", cmd.Args[2], err)
+		}
+		if string(out) != tt.want {
+			t.Errorf("%s printed %q, want %q", cmd.Args[2], out, tt.want)
+		}
+	}
+}
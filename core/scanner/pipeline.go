@@ -0,0 +1,236 @@
+// core/scanner/pipeline.go
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+
+	"smuggr.xyz/thughunter/common/models"
+	"smuggr.xyz/thughunter/core/findings"
+)
+
+// A scan is a pipeline: capture workers feed analysis workers, and one
+// collector owns the results. Retried targets go back to the dispatcher.
+
+type job struct {
+	Host    models.Host
+	Port    int
+	Attempt int
+}
+
+func (j job) Target() Target {
+	return Target{IP: j.Host.IP, Port: j.Port}
+}
+
+func (j job) String() string {
+	return j.Target().String()
+}
+
+func (j job) filename() string {
+	return snapshotName(j.Host.IP, j.Port)
+}
+
+type shot struct {
+	job
+	Output string
+	Err    *CaptureError
+}
+
+type outcome struct {
+	job
+	Working bool
+	Failure *Failure
+}
+
+// scanOutcome is what the collector gathered.
+type scanOutcome struct {
+	Working []Result
+	Failed  []Failure
+	Blank   []Failure
+	Scanned []findings.Target
+}
+
+// targetSource calls emit for every host to scan.
+type targetSource func(emit func(models.Host)) error
+
+// performParallelSnapshots scans the hosts from source with c.
+func performParallelSnapshots(c Capturer, snapshotDir, discardedDir string, source targetSource) (scanOutcome, error) {
+	fresh := make(chan job)
+	retries := make(chan job)
+	targets := make(chan job)
+	shots := make(chan shot)
+	outcomes := make(chan outcome)
+
+	// inflight counts targets not yet collected, retries included.
+	var inflight sync.WaitGroup
+	var sourceErr error
+	go func() {
+		sourceErr = dispatch(source, fresh)
+		close(fresh)
+	}()
+	go func() {
+		defer close(targets)
+		done := make(chan struct{})
+		for fresh != nil {
+			select {
+			case j, ok := <-fresh:
+				if !ok {
+					fresh = nil
+					go func() {
+						inflight.Wait()
+						close(done)
+					}()
+					break
+				}
+				inflight.Add(1)
+				targets <- j
+			case j := <-retries:
+				targets <- j
+			}
+		}
+		for {
+			select {
+			case j := <-retries:
+				targets <- j
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var captures sync.WaitGroup
+	for i := 0; i < getConcurrencyLimit(); i++ {
+		captures.Add(1)
+		go func() {
+			defer captures.Done()
+			for t := range targets {
+				shots <- captureSnapshot(c, snapshotDir, t)
+			}
+		}()
+	}
+	go func() {
+		captures.Wait()
+		close(shots)
+	}()
+
+	var analysis sync.WaitGroup
+	for i := 0; i < runtime.NumCPU(); i++ {
+		analysis.Add(1)
+		go func() {
+			defer analysis.Done()
+			for s := range shots {
+				o := analyze(discardedDir, s)
+				if o.Failure != nil && policy.retry(o.Failure.Kind, o.Attempt) {
+					next := o.job
+					next.Attempt++
+					wait := policy.delay(o.Attempt)
+					fmt.Printf("[~] %s - Retrying in %s (attempt %d of %d)\n", next, wait, next.Attempt, policy.attempts)
+					time.AfterFunc(wait, func() { retries <- next })
+					continue
+				}
+				outcomes <- o
+				inflight.Done()
+			}
+		}()
+	}
+	go func() {
+		analysis.Wait()
+		close(outcomes)
+	}()
+
+	out := collect(outcomes)
+	return out, sourceErr
+}
+
+func dispatch(source targetSource, targets chan<- job) error {
+	return source(func(h models.Host) {
+		if port, ok := h.Services["VNC"]; ok {
+			targets <- job{Host: h, Port: port, Attempt: 1}
+		}
+	})
+}
+
+func captureSnapshot(c Capturer, snapshotDir string, j job) shot {
+	output := filepath.Join(snapshotDir, j.filename())
+	ctx, cancel := context.WithTimeout(context.Background(), policy.timeout(j.Attempt))
+	defer cancel()
+
+	err := c.Capture(ctx, j.Target(), output)
+	return shot{job: j, Output: output, Err: asCaptureError(ctx, j.Target(), err)}
+}
+
+func (s shot) failure(kind FailureKind, detail string) *Failure {
+	if detail == "" && s.Err != nil && s.Err.Err != nil {
+		detail = s.Err.Err.Error()
+	}
+	return &Failure{IP: s.Host.IP, Port: s.Port, Kind: kind, Detail: detail, Attempts: s.Attempt}
+}
+
+func analyze(discardedDir string, s shot) outcome {
+	switch {
+	case s.Err != nil && s.Err.Kind == FailTimeout:
+		fmt.Printf("[!] %s - Timeout\n", s.job)
+		return outcome{job: s.job, Failure: s.failure(FailTimeout, "")}
+	case s.Err != nil:
+		fmt.Printf("[-] %v\n", s.Err)
+		return outcome{job: s.job, Failure: s.failure(s.Err.Kind, s.Err.Detail)}
+	case isSingleColorImage(s.Output):
+		fmt.Printf("[-] %s - Discarded single-color image\n", s.job)
+		os.Rename(s.Output, filepath.Join(discardedDir, s.filename()))
+		return outcome{job: s.job, Failure: s.failure(FailBlank, "single-color image")}
+	}
+	fmt.Printf("[+] %s - Snapshot saved\n", s.job)
+	return outcome{job: s.job, Working: true}
+}
+
+// findingOutcome closes findings on definitive failures only.
+func (o outcome) findingOutcome() findings.Outcome {
+	if o.Working {
+		return findings.Exposed
+	}
+	switch o.Failure.Kind {
+	case FailRefused, FailAuthRequired, FailSecurityType:
+		return findings.Closed
+	}
+	return findings.Inconclusive
+}
+
+// collect is the only reader of outcomes, so the results need no lock.
+func collect(outcomes <-chan outcome) scanOutcome {
+	var out scanOutcome
+	for o := range outcomes {
+		h := o.Host
+		out.Scanned = append(out.Scanned, findings.Target{
+			Host:    models.Host{IP: h.IP, Hostname: h.Hostname, Location: h.Location},
+			Port:    o.Port,
+			Outcome: o.findingOutcome(),
+		})
+		switch {
+		case o.Working:
+			out.Working = append(out.Working, Result{
+				IP:       h.IP,
+				Port:     o.Port,
+				Filename: filepath.ToSlash(filepath.Join("snapshots", o.filename())),
+				Hostname: h.Hostname,
+				Labels:   h.Labels,
+				Location: h.Location,
+				Services: h.Services,
+				Country:  h.Country,
+				City:     h.City,
+				ASN:      h.ASN,
+				Org:      h.Org,
+				Attempts: o.Attempt,
+			})
+		case o.Failure.Kind == FailBlank:
+			out.Blank = append(out.Blank, *o.Failure)
+		default:
+			out.Failed = append(out.Failed, *o.Failure)
+		}
+	}
+	return out
+}
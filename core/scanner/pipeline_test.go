@@ -0,0 +1,225 @@
+package scanner_test
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"smuggr.xyz/thughunter/common/models"
+	"smuggr.xyz/thughunter/core/config"
+	"smuggr.xyz/thughunter/core/scanner"
+	"smuggr.xyz/thughunter/core/scanner/scannertest"
+)
+
+func configure(t *testing.T, c config.Scanner) {
+	t.Helper()
+	if c.Timeout == 0 {
+		c.Timeout = time.Second
+	}
+	if c.Attempts == 0 {
+		c.Attempts = 1
+	}
+	if err := scanner.Configure(c); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func scanDirs(t *testing.T) (string, string) {
+	t.Helper()
+	snapshots := t.TempDir()
+	discarded := filepath.Join(snapshots, "discarded")
+	if err := os.Mkdir(discarded, 0755); err != nil {
+		t.Fatal(err)
+	}
+	return snapshots, discarded
+}
+
+func vncHost(ip string) models.Host {
+	return models.Host{IP: ip, Services: models.JSONServiceMap{"VNC": 5900}}
+}
+
+func captureErr(ip string, kind scanner.FailureKind) error {
+	return &scanner.CaptureError{Target: scanner.Target{IP: ip, Port: 5900}, Kind: kind, Detail: string(kind)}
+}
+
+func TestPipelineOutcomes(t *testing.T) {
+	configure(t, config.Scanner{})
+	fake := &scannertest.Fake{
+		Errors: map[string]error{
+			"192.0.2.2::5900": captureErr("192.0.2.2", scanner.FailTimeout),
+			"192.0.2.3::5900": captureErr("192.0.2.3", scanner.FailRefused),
+			"192.0.2.4::5900": captureErr("192.0.2.4", scanner.FailAuthRequired),
+			"192.0.2.5::5900": captureErr("192.0.2.5", scanner.FailSecurityType),
+			"192.0.2.6::5900": captureErr("192.0.2.6", scanner.FailProtocol),
+			"192.0.2.7::5900": errors.New("exec: vncsnapshot not found"),
+		},
+		Images: map[string]image.Image{
+			"192.0.2.8::5900": scannertest.Blank(1024, 768, color.Black),
+		},
+	}
+	hosts := []models.Host{
+		vncHost("192.0.2.1"), vncHost("192.0.2.2"), vncHost("192.0.2.3"), vncHost("192.0.2.4"),
+		vncHost("192.0.2.5"), vncHost("192.0.2.6"), vncHost("192.0.2.7"), vncHost("192.0.2.8"),
+		{IP: "192.0.2.9", Services: models.JSONServiceMap{"HTTP": 80}},
+		vncHost("2001:db8::1"),
+	}
+	snapshots, discarded := scanDirs(t)
+
+	out, err := scanner.PerformParallelSnapshots(fake, snapshots, discarded, hosts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(fake.Calls()) != 9 {
+		t.Errorf("%d captures, want 9, hosts without VNC are skipped", len(fake.Calls()))
+	}
+	if len(out.Scanned) != 9 {
+		t.Errorf("%d scanned, want 9", len(out.Scanned))
+	}
+	working := map[string]bool{}
+	for _, w := range out.Working {
+		working[w.IP] = true
+		if _, err := os.Stat(filepath.Join(snapshots, filepath.Base(w.Filename))); err != nil {
+			t.Errorf("%s: snapshot missing: %v", w.IP, err)
+		}
+	}
+	if len(working) != 2 || !working["192.0.2.1"] || !working["2001:db8::1"] {
+		t.Errorf("working = %v, want 192.0.2.1 and 2001:db8::1", working)
+	}
+
+	kinds := map[string]scanner.FailureKind{}
+	for _, f := range out.Failed {
+		kinds[f.IP] = f.Kind
+	}
+	want := map[string]scanner.FailureKind{
+		"192.0.2.2": scanner.FailTimeout,
+		"192.0.2.3": scanner.FailRefused,
+		"192.0.2.4": scanner.FailAuthRequired,
+		"192.0.2.5": scanner.FailSecurityType,
+		"192.0.2.6": scanner.FailProtocol,
+		"192.0.2.7": scanner.FailUnknown,
+	}
+	if len(kinds) != len(want) {
+		t.Errorf("failed = %v, want %v", kinds, want)
+	}
+	for ip, k := range want {
+		if kinds[ip] != k {
+			t.Errorf("%s failed with %q, want %q", ip, kinds[ip], k)
+		}
+	}
+
+	if len(out.Blank) != 1 || out.Blank[0].IP != "192.0.2.8" || out.Blank[0].Kind != scanner.FailBlank {
+		t.Errorf("blank = %+v, want 192.0.2.8", out.Blank)
+	}
+	if _, err := os.Stat(filepath.Join(discarded, "192.0.2.8_5900.png")); err != nil {
+		t.Errorf("blank snapshot not moved to discarded: %v", err)
+	}
+}
+
+func TestPipelineTimeout(t *testing.T) {
+	configure(t, config.Scanner{Timeout: 20 * time.Millisecond})
+	fake := &scannertest.Fake{Delay: time.Second}
+	snapshots, discarded := scanDirs(t)
+
+	out, err := scanner.PerformParallelSnapshots(fake, snapshots, discarded, []models.Host{vncHost("192.0.2.1")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Failed) != 1 || out.Failed[0].Kind != scanner.FailTimeout {
+		t.Errorf("failed = %+v, want one timeout", out.Failed)
+	}
+}
+
+// flaky fails each target a fixed number of times before letting the fake
+// capture it.
+type flaky struct {
+	*scannertest.Fake
+	err error
+
+	mu    sync.Mutex
+	fails map[string]int
+}
+
+func (f *flaky) Capture(ctx context.Context, t scanner.Target, output string) error {
+	f.mu.Lock()
+	n := f.fails[t.String()]
+	if n > 0 {
+		f.fails[t.String()] = n - 1
+	}
+	f.mu.Unlock()
+	if n > 0 {
+		return f.err
+	}
+	return f.Fake.Capture(ctx, t, output)
+}
+
+func TestPipelineRetries(t *testing.T) {
+	configure(t, config.Scanner{Attempts: 3, RetryBackoff: 20 * time.Millisecond, RetryOn: "connection refused"})
+	fake := &scannertest.Fake{Errors: map[string]error{
+		"192.0.2.3::5900": captureErr("192.0.2.3", scanner.FailRefused),
+		"192.0.2.4::5900": captureErr("192.0.2.4", scanner.FailAuthRequired),
+	}}
+	c := &flaky{
+		Fake:  fake,
+		err:   captureErr("192.0.2.1", scanner.FailRefused),
+		fails: map[string]int{"192.0.2.1::5900": 2},
+	}
+	snapshots, discarded := scanDirs(t)
+
+	start := time.Now()
+	out, err := scanner.PerformParallelSnapshots(c, snapshots, discarded,
+		[]models.Host{vncHost("192.0.2.1"), vncHost("192.0.2.3"), vncHost("192.0.2.4")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Two retries wait 20ms and then 40ms.
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Errorf("scan took %s, the backoff was not waited", elapsed)
+	}
+
+	if len(out.Working) != 1 || out.Working[0].IP != "192.0.2.1" || out.Working[0].Attempts != 3 {
+		t.Errorf("working = %+v, want 192.0.2.1 after 3 attempts", out.Working)
+	}
+	attempts := map[string]int{}
+	for _, f := range out.Failed {
+		attempts[f.IP] = f.Attempts
+	}
+	if attempts["192.0.2.3"] != 3 {
+		t.Errorf("refused target made %d attempts, want 3", attempts["192.0.2.3"])
+	}
+	if attempts["192.0.2.4"] != 1 {
+		t.Errorf("auth target made %d attempts, want 1", attempts["192.0.2.4"])
+	}
+	if len(out.Scanned) != 3 {
+		t.Errorf("%d scanned, retries must be collected once", len(out.Scanned))
+	}
+}
+
+func TestPipelineTimeoutStep(t *testing.T) {
+	configure(t, config.Scanner{
+		Timeout:      30 * time.Millisecond,
+		TimeoutStep:  200 * time.Millisecond,
+		Attempts:     2,
+		RetryBackoff: time.Millisecond,
+		RetryOn:      "timeout",
+	})
+	fake := &scannertest.Fake{Delay: 100 * time.Millisecond}
+	snapshots, discarded := scanDirs(t)
+
+	out, err := scanner.PerformParallelSnapshots(fake, snapshots, discarded, []models.Host{vncHost("192.0.2.1")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Working) != 1 || out.Working[0].Attempts != 2 {
+		t.Errorf("working = %+v, want a snapshot on the longer second attempt", out.Working)
+	}
+	if n := len(fake.Calls()); n != 2 {
+		t.Errorf("%d captures, want 2", n)
+	}
+}
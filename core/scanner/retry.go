@@ -0,0 +1,52 @@
+// core/scanner/retry.go
+package scanner
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+var failureKinds = []FailureKind{FailTimeout, FailRefused, FailAuthRequired, FailSecurityType, FailProtocol, FailBlank, FailUnknown}
+
+// retryPolicy decides which failures are retried and how.
+type retryPolicy struct {
+	attempts int
+	backoff  time.Duration
+	step     time.Duration
+	on       map[FailureKind]bool
+}
+
+var policy = retryPolicy{attempts: 1}
+
+func parseRetryOn(list string) (map[FailureKind]bool, error) {
+	on := make(map[FailureKind]bool)
+	for _, item := range strings.Split(list, ",") {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if item == "" {
+			continue
+		}
+		known := false
+		for _, k := range failureKinds {
+			if string(k) == item {
+				on[k], known = true, true
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("scanner.retry_on: unknown failure category %q", item)
+		}
+	}
+	return on, nil
+}
+
+func (p retryPolicy) retry(kind FailureKind, attempt int) bool {
+	return attempt < p.attempts && p.on[kind]
+}
+
+func (p retryPolicy) delay(attempt int) time.Duration {
+	return p.backoff << (attempt - 1)
+}
+
+func (p retryPolicy) timeout(attempt int) time.Duration {
+	return cfg.Timeout + time.Duration(attempt-1)*p.step
+}
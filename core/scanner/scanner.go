@@ -3,11 +3,11 @@ package scanner
 
 import (
 	"bufio"
-	"bytes"
-	"context"
+	"encoding/json"
 	"fmt"
-	"image"
+	"html"
 	_ "image/png"
+	"net"
 	"net/http"
 	"os"
 	"os/exec"
@@ -15,11 +15,15 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"smuggr.xyz/thughunter/common/models"
+	"smuggr.xyz/thughunter/core/audit"
+	"smuggr.xyz/thughunter/core/config"
+	"smuggr.xyz/thughunter/core/control"
 	"smuggr.xyz/thughunter/core/datastore"
+	"smuggr.xyz/thughunter/core/findings"
+	"smuggr.xyz/thughunter/core/notify"
 )
 
 type Result struct {
@@ -30,29 +34,58 @@ type Result struct {
 	Labels   []string
 	Location string
 	Services map[string]int
+	Country  string
+	City     string
+	ASN      uint
+	Org      string
+	Attempts int
+}
+
+var cfg config.Scanner
+
+func Configure(c config.Scanner) error {
+	on, err := parseRetryOn(c.RetryOn)
+	if err != nil {
+		return err
+	}
+	cfg = c
+	policy = retryPolicy{attempts: c.Attempts, backoff: c.RetryBackoff, step: c.TimeoutStep, on: on}
+	return nil
 }
 
 func StartControlServer() {
-	http.HandleFunc("/open-vnc", func(w http.ResponseWriter, r *http.Request) {
-		ip := r.URL.Query().Get("ip")
-		port := r.URL.Query().Get("port")
+	http.HandleFunc("OPTIONS /open-vnc", control.Authorized(nil))
+	http.HandleFunc("POST /open-vnc", control.Authorized(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			IP   string `json:"ip"`
+			Port string `json:"port"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid JSON body", http.StatusBadRequest)
+			return
+		}
+		ip, port := req.IP, req.Port
 		if ip == "" || port == "" {
 			http.Error(w, "Missing ip or port", http.StatusBadRequest)
 			return
 		}
-
-		template := os.Getenv("LAUNCH_VNC_COMMAND")
-		if template == "" {
-			http.Error(w, "LAUNCH_VNC_COMMAND not set", http.StatusInternalServerError)
+		// Both go into a shell command.
+		if net.ParseIP(ip) == nil {
+			http.Error(w, "Invalid ip", http.StatusBadRequest)
+			return
+		}
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			http.Error(w, "Invalid port", http.StatusBadRequest)
 			return
 		}
 
-		if strings.Count(template, "%s") < 2 {
-			http.Error(w, "LAUNCH_VNC_COMMAND must contain two %s placeholders (for IP and PORT)", http.StatusInternalServerError)
+		template := control.Settings().LaunchCommand
+		if template == "" {
+			http.Error(w, "LAUNCH_VNC_COMMAND not set", http.StatusInternalServerError)
 			return
 		}
 
-		cmdStr := fmt.Sprintf(template, ip, port)
+		cmdStr := launchCommand(template, ip, port)
 		cmd := exec.Command("sh", "-c", cmdStr)
 
 		cmd.Stdout = nil
@@ -64,102 +97,163 @@ func StartControlServer() {
 			http.Error(w, "Failed to start VNC client", http.StatusInternalServerError)
 			return
 		}
+		audit.Record(audit.ActionOpenVNC, map[string]string{
+			"ip":      ip,
+			"port":    port,
+			"command": cmdStr,
+			"remote":  r.RemoteAddr,
+			"origin":  r.Header.Get("Origin"),
+		})
 
-		w.WriteHeader(http.StatusOK)
-	})
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	control.RegisterLogin()
 
-	addr := os.Getenv("CONTROL_SERVER_ADDR")
-	if addr == "" {
-		addr = "127.0.0.1:7373"
-	}
+	addr := control.Settings().Addr
+	control.Token()
 	fmt.Printf("Control server listening at http://%s\n", addr)
 	go http.ListenAndServe(addr, nil)
 }
 
-func RunScan(reader *bufio.Reader) {
-	timestamp := time.Now().Format("2006-01-02_15-04-05")
-	scansDir := os.Getenv("SCANS_PATH")
-	if scansDir == "" {
-		scansDir = filepath.Join("scans", timestamp)
-	} else {
-		scansDir = filepath.Join(scansDir, timestamp)
-	}
-
-	snapshotDir := filepath.Join(scansDir, "snapshots")
-	discardedDir := filepath.Join(snapshotDir, "discarded")
-	os.MkdirAll(discardedDir, 0755)
-
-	genHTML := askGenerateHTML(reader)
-
-	var hosts []models.Host
-	datastore.DB.Find(&hosts)
+// launchCommand fills the viewer template with the shell-quoted address
+// and port, so the brackets of an IPv6 address are not read as a glob.
+func launchCommand(template, ip, port string) string {
+	return fmt.Sprintf(template, shellQuote(bracketIP(ip)), shellQuote(port))
+}
 
-	working, failed, discardedCount := performParallelSnapshots(snapshotDir, discardedDir, hosts)
-	writeReport(scansDir, working, failed, discardedCount)
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
 
-	if genHTML {
-		writeHTMLSummary(scansDir, working, failed, discardedCount)
-	}
+type ScanOptions struct {
+	HTML       bool
+	OpenReport bool
+	Notify     bool
+	DryRun     bool
+	Targets    TargetSelection
+	// Capturer takes the snapshots, VNCSnapshot{} when nil.
+	Capturer Capturer
 }
 
-func askGenerateHTML(r *bufio.Reader) bool {
-	fmt.Print("Generate HTML summary? (y/N): ")
-	resp, _ := r.ReadString('\n')
-	return strings.ToLower(strings.TrimSpace(resp)) == "y"
+type ScanSummary struct {
+	Dir       string
+	Targets   int
+	Working   []Result
+	Failed    []Failure
+	Discarded int
 }
 
-func isSingleColorImage(path string) bool {
-	info, err := os.Stat(path)
-	if err != nil {
-		fmt.Printf("[!] Failed to stat image: %v\n", err)
-		return false
+func RunScan(reader *bufio.Reader) {
+	sel, ok := askTargetSelection(reader)
+	if !ok {
+		return
 	}
-	if info.Size() < 1024 {
-		fmt.Printf("[!] Image too small or empty: %s\n", path)
-		return false
+	fmt.Print("Dry run, only list targets? (y/N): ")
+	resp, _ := reader.ReadString('\n')
+	opts := ScanOptions{DryRun: true, Targets: sel}
+	if strings.ToLower(strings.TrimSpace(resp)) != "y" {
+		opts = ScanOptions{HTML: askGenerateHTML(reader), OpenReport: true, Targets: sel}
 	}
+	if _, err := Scan(opts); err != nil {
+		fmt.Println("Error selecting targets:", err)
+	}
+}
 
-	data, err := os.ReadFile(path)
+func Scan(opts ScanOptions) (ScanSummary, error) {
+	if opts.DryRun {
+		n := 0
+		err := StreamTargets(opts.Targets, func(hosts []models.Host) error {
+			printTargets(hosts)
+			n += len(hosts)
+			return nil
+		})
+		if err != nil {
+			return ScanSummary{}, err
+		}
+		fmt.Printf("Dry run: %d targets would be scanned\n", n)
+		return ScanSummary{Targets: n}, nil
+	}
+	total, err := CountTargets(opts.Targets)
 	if err != nil {
-		fmt.Printf("[!] Failed to read image: %v\n", err)
-		return false
+		return ScanSummary{}, err
 	}
 
-	fmt.Printf("[?] Header of %s: % x\n", path, data[:12])
+	timestamp := time.Now().Format("2006-01-02_15-04-05")
+	scansDir := filepath.Join(cfg.ScansPath, timestamp)
 
-	contentType := http.DetectContentType(data[:512])
-	if !strings.HasPrefix(contentType, "image/png") {
-		fmt.Printf("[!] Invalid image type (%s): %s\n", contentType, path)
-		return false
-	}
+	snapshotDir := filepath.Join(scansDir, "snapshots")
+	discardedDir := filepath.Join(snapshotDir, "discarded")
+	os.MkdirAll(discardedDir, 0755)
 
-	img, _, err := image.Decode(bytes.NewReader(data))
+	summary := ScanSummary{Dir: scansDir, Targets: total}
+	run := models.ScanRun{StartedAt: time.Now(), Dir: scansDir, Selection: opts.Targets.String(), Targets: summary.Targets}
+	datastore.DB.Create(&run)
+	audit.Record(audit.ActionScanStart, map[string]string{
+		"scan_dir":  scansDir,
+		"selection": run.Selection,
+		"targets":   strconv.Itoa(summary.Targets),
+	})
+	capturer := opts.Capturer
+	if capturer == nil {
+		capturer = VNCSnapshot{}
+	}
+	out, err := performParallelSnapshots(capturer, snapshotDir, discardedDir, func(emit func(models.Host)) error {
+		return StreamTargets(opts.Targets, func(hosts []models.Host) error {
+			for _, h := range hosts {
+				emit(h)
+			}
+			return nil
+		})
+	})
 	if err != nil {
-		fmt.Printf("[!] Failed to decode image: %v\n", err)
-		return false
+		fmt.Printf("[!] Reading targets failed, the scan covers %d of %d: %v\n", len(out.Scanned), total, err)
+	}
+	summary.Working, summary.Failed, summary.Discarded = out.Working, out.Failed, len(out.Blank)
+	saveResults(run.ID, out)
+	if err := writeSnapshotIndex(snapshotDir, out); err != nil {
+		fmt.Printf("[!] Failed to write snapshot index: %v\n", err)
+	}
+	audit.Record(audit.ActionScanEnd, map[string]string{
+		"scan_dir":  scansDir,
+		"working":   strconv.Itoa(len(summary.Working)),
+		"failed":    strconv.Itoa(len(summary.Failed)),
+		"discarded": strconv.Itoa(summary.Discarded),
+		"breakdown": FormatBreakdown(summary.Breakdown()),
+	})
+	finished := time.Now()
+	run.FinishedAt = &finished
+	run.Working, run.Failed, run.Discarded = len(summary.Working), len(summary.Failed), summary.Discarded
+	datastore.DB.Save(&run)
+	writeReport(scansDir, summary)
+
+	findings.Reconcile(out.Scanned, scansDir, time.Now())
+	if opts.Notify {
+		announceFindings(timestamp)
 	}
 
-	bounds := img.Bounds()
-	first := img.At(bounds.Min.X, bounds.Min.Y)
-	r0, g0, b0, _ := first.RGBA()
-	const tolerance = 0x0100
-
-	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
-		for x := bounds.Min.X; x < bounds.Max.X; x++ {
-			r, g, b, _ := img.At(x, y).RGBA()
-			if absDiff(r0, r) > tolerance || absDiff(g0, g) > tolerance || absDiff(b0, b) > tolerance {
-				return false
-			}
-		}
+	if opts.HTML {
+		writeHTMLSummary(scansDir, summary, opts.OpenReport)
 	}
-	return true
+	return summary, nil
 }
 
-func absDiff(a, b uint32) uint32 {
-	if a > b {
-		return a - b
+// announceFindings notifies every change not announced yet, including
+// those found by scans that did not notify.
+func announceFindings(timestamp string) {
+	pending, err := findings.Pending()
+	if err != nil {
+		fmt.Printf("[!] Failed to load unannounced findings: %v\n", err)
+		return
+	}
+	if notify.Send(notify.Batch{Run: "scan " + timestamp, Time: time.Now(), Changes: pending}) {
+		findings.MarkNotified(pending)
 	}
-	return b - a
+}
+
+func askGenerateHTML(r *bufio.Reader) bool {
+	fmt.Print("Generate HTML summary? (y/N): ")
+	resp, _ := r.ReadString('\n')
+	return strings.ToLower(strings.TrimSpace(resp)) == "y"
 }
 
 func openFile(path string) error {
@@ -177,85 +271,9 @@ func openFile(path string) error {
 	return cmd.Start()
 }
 
-func performParallelSnapshots(snapshotDir, discardedDir string, hosts []models.Host) ([]Result, []string, int) {
-	var rawResults []Result
-	var discarded int
-	var failed []string
-	var mu sync.Mutex
-	var wg sync.WaitGroup
-
-	maxConcurrent := getConcurrencyLimit()
-	sem := make(chan struct{}, maxConcurrent)
-
-	for _, host := range hosts {
-		port, ok := host.Services["VNC"]
-		if !ok {
-			continue
-		}
-
-		wg.Add(1)
-		sem <- struct{}{}
-
-		go func(h models.Host, p int) {
-			defer wg.Done()
-			defer func() { <-sem }()
-
-			target := fmt.Sprintf("%s::%d", h.IP, p)
-			filename := fmt.Sprintf("%s:%d.png", h.IP, p)
-			output := filepath.Join(snapshotDir, filename)
-
-			timeout := 6 * time.Second
-			if toStr := os.Getenv("TIMEOUT_DEFAULT"); toStr != "" {
-				if toVal, err := strconv.Atoi(toStr); err == nil && toVal > 0 {
-					timeout = time.Duration(toVal) * time.Second
-				}
-			}
-			ctx, cancel := context.WithTimeout(context.Background(), timeout)
-			defer cancel()
-
-			err := exec.CommandContext(ctx, "vncsnapshot", "-quiet", "-ignoreblank", target, output).Run()
-
-			mu.Lock()
-			defer mu.Unlock()
-
-			if ctx.Err() == context.DeadlineExceeded {
-				fmt.Printf("[!] %s - Timeout\n", target)
-				failed = append(failed, target)
-			} else if err != nil {
-				fmt.Printf("[-] %s - Error: %v\n", target, err)
-				failed = append(failed, target)
-			} else {
-				if isSingleColorImage(output) {
-					fmt.Printf("[-] %s:%d - Discarded single-color image\n", h.IP, p)
-					discardPath := filepath.Join(discardedDir, filename)
-					os.Rename(output, discardPath)
-					failed = append(failed, fmt.Sprintf("%s:%d", h.IP, p))
-					discarded++
-					return
-				}
-				fmt.Printf("[+] %s - Snapshot saved\n", target)
-				rawResults = append(rawResults, Result{
-					IP:       h.IP,
-					Port:     p,
-					Filename: filepath.Join("snapshots", filename),
-					Hostname: h.Hostname,
-					Labels:   h.Labels,
-					Location: h.Location,
-					Services: h.Services,
-				})
-			}
-		}(host, port)
-	}
-
-	wg.Wait()
-	return rawResults, failed, discarded
-}
-
 func getConcurrencyLimit() int {
-	if maxStr := os.Getenv("MAX_CONCURRENT_VNC"); maxStr != "" {
-		if val, err := strconv.Atoi(maxStr); err == nil && val > 0 {
-			return val
-		}
+	if cfg.MaxConcurrent > 0 {
+		return cfg.MaxConcurrent
 	}
 	cpu := runtime.NumCPU()
 	limit := cpu * 4
@@ -267,7 +285,27 @@ func getConcurrencyLimit() int {
 	return limit
 }
 
-func writeReport(dir string, working []Result, failed []string, discardedCount int) {
+// saveResults stores the outcome of every target of a scan run.
+func saveResults(runID uint, out scanOutcome) {
+	rows := make([]models.ScanResult, 0, len(out.Working)+len(out.Failed)+len(out.Blank))
+	for _, w := range out.Working {
+		rows = append(rows, models.ScanResult{ScanRunID: runID, IP: w.IP, Port: w.Port, Status: ResultWorking, Attempts: w.Attempts})
+	}
+	for _, f := range out.Failed {
+		rows = append(rows, models.ScanResult{ScanRunID: runID, IP: f.IP, Port: f.Port, Status: ResultFailed, Category: string(f.Kind), Detail: f.Detail, Attempts: f.Attempts})
+	}
+	for _, f := range out.Blank {
+		rows = append(rows, models.ScanResult{ScanRunID: runID, IP: f.IP, Port: f.Port, Status: ResultDiscarded, Category: string(f.Kind), Detail: f.Detail, Attempts: f.Attempts})
+	}
+	if len(rows) == 0 {
+		return
+	}
+	if err := datastore.DB.CreateInBatches(&rows, 500).Error; err != nil {
+		fmt.Printf("[!] Failed to store scan results: %v\n", err)
+	}
+}
+
+func writeReport(dir string, summary ScanSummary) {
 	now := time.Now()
 	dateStr := now.Format("2006-01-02_15-04-05")
 	path := filepath.Join(dir, fmt.Sprintf("thug_hunting_%s.txt", dateStr))
@@ -278,30 +316,89 @@ func writeReport(dir string, working []Result, failed []string, discardedCount i
 	}
 	defer file.Close()
 
-	file.WriteString(fmt.Sprintf("VNC Thug-Hunting Report — %s\n\n", now.Format("2006-01-02 15:04:05")))
-	file.WriteString(fmt.Sprintf("Total Discarded: %d\n\n", discardedCount))
+	file.WriteString(fmt.Sprintf("%s — %s\n", reportTitle("VNC Thug-Hunting Report"), now.Format("2006-01-02 15:04:05")))
+	file.WriteString(fmt.Sprintf("Workspace: %s\n\n", workspaceTitle()))
+	file.WriteString(fmt.Sprintf("Total Discarded: %d\n\n", summary.Discarded))
 	file.WriteString("Working VNC services:\n")
-	for _, w := range working {
-		file.WriteString(fmt.Sprintf("%s:%d\n", w.IP, w.Port))
+	for _, w := range summary.Working {
+		line := Target{IP: w.IP, Port: w.Port}.Addr()
+		if w.Attempts > 1 {
+			line += fmt.Sprintf("\tafter %d attempts", w.Attempts)
+		}
+		file.WriteString(line + "\n")
 	}
 	file.WriteString("\nFailed VNC services:\n")
-	for _, f := range failed {
-		file.WriteString(f + "\n")
+	for _, f := range summary.Failed {
+		line := fmt.Sprintf("%s\t%s", Target{IP: f.IP, Port: f.Port}.Addr(), f.Kind)
+		if f.Detail != "" {
+			line += "\t" + f.Detail
+		}
+		if f.Attempts > 1 {
+			line += fmt.Sprintf("\t(%d attempts)", f.Attempts)
+		}
+		file.WriteString(line + "\n")
+	}
+	file.WriteString("\nFailures by category:\n")
+	for _, c := range summary.Breakdown() {
+		file.WriteString(fmt.Sprintf("%-26s %d\n", c.Kind, c.Count))
+	}
+	if footer := cfg.Workspace.Branding.Footer; footer != "" {
+		file.WriteString("\n" + footer + "\n")
 	}
 	fmt.Println("📄 Report saved")
 }
 
-func renderInfoText(hostname, location string) string {
+func reportTitle(fallback string) string {
+	if t := cfg.Workspace.Branding.ReportTitle; t != "" {
+		return t
+	}
+	return fallback
+}
+
+func workspaceTitle() string {
+	if cfg.Workspace.Name == "" {
+		return "no workspace"
+	}
+	return cfg.Workspace.Title()
+}
+
+func renderFooter() string {
+	footer := cfg.Workspace.Branding.Footer
+	if footer == "" {
+		return ""
+	}
+	return `<footer class="stats">` + html.EscapeString(footer) + `</footer>`
+}
+
+func renderInfoText(r Result) string {
 	var b strings.Builder
-	if hostname != "" {
-		b.WriteString(fmt.Sprintf("<p><strong>Hostname:</strong> %s</p>", hostname))
+	if r.Hostname != "" {
+		b.WriteString(fmt.Sprintf("<p><strong>Hostname:</strong> %s</p>", html.EscapeString(r.Hostname)))
 	}
-	if location != "" {
-		b.WriteString(fmt.Sprintf("<p><strong>Location:</strong> %s</p>", location))
+	if r.Location != "" {
+		b.WriteString(fmt.Sprintf("<p><strong>Location:</strong> %s</p>", html.EscapeString(r.Location)))
+	}
+	if r.Country != "" {
+		geo := r.Country
+		if r.City != "" {
+			geo = r.City + ", " + r.Country
+		}
+		b.WriteString(fmt.Sprintf("<p><strong>GeoIP:</strong> %s</p>", html.EscapeString(geo)))
+	}
+	if r.ASN != 0 {
+		b.WriteString(fmt.Sprintf("<p><strong>ASN:</strong> AS%d %s</p>", r.ASN, html.EscapeString(r.Org)))
 	}
 	return b.String()
 }
 
+func geoSearchText(r Result) string {
+	parts := []string{r.Country, r.City, r.Org, r.Location}
+	if r.ASN != 0 {
+		parts = append(parts, fmt.Sprintf("AS%d", r.ASN))
+	}
+	return strings.ToLower(strings.Join(parts, " "))
+}
+
 func renderLabels(labels []string) string {
 	if len(labels) == 0 {
 		return ""
@@ -312,7 +409,7 @@ func renderLabels(labels []string) string {
 		if i > 0 {
 			b.WriteString(", ")
 		}
-		b.WriteString(`<span class="label">` + label + `</span>`)
+		b.WriteString(`<span class="label">` + html.EscapeString(label) + `</span>`)
 	}
 	b.WriteString("</p>")
 	return b.String()
@@ -328,13 +425,13 @@ func renderServices(services map[string]int) string {
 		if name == "VNC" {
 			continue
 		}
-		b.WriteString(fmt.Sprintf("<li>%s: %d</li>", name, port))
+		b.WriteString(fmt.Sprintf("<li>%s: %d</li>", html.EscapeString(name), port))
 	}
 	b.WriteString("</ul></p>")
 	return b.String()
 }
 
-func writeHTMLSummary(dir string, working []Result, failed []string, discarededCount int) {
+func writeHTMLSummary(dir string, summary ScanSummary, open bool) {
 	now := time.Now()
 	dateStr := now.Format("2006-01-02_15-04-05")
 	path := filepath.Join(dir, fmt.Sprintf("thug_hunting_%s.html", dateStr))
@@ -353,17 +450,19 @@ func writeHTMLSummary(dir string, working []Result, failed []string, discarededC
 	vncConnectSVG, _ := os.ReadFile("./assets/vnc_connect.svg")
 	hostInfoSVG, _ := os.ReadFile("./assets/host_info.svg")
 
-	failedCount := len(failed)
-	totalCount := len(working) + failedCount
-	var allHosts []models.Host
-	datastore.DB.Find(&allHosts)
-	totalHosts := len(allHosts)
+	working := summary.Working
+	var breakdown strings.Builder
+	for _, c := range summary.Breakdown() {
+		breakdown.WriteString(fmt.Sprintf(` <span class="failure">%s: %d</span>`, html.EscapeString(string(c.Kind)), c.Count))
+	}
+	var totalHosts int64
+	datastore.DB.Model(&models.Host{}).Count(&totalHosts)
 
 	f.WriteString(`<!DOCTYPE html>
 <html lang="en" data-theme="dark">
 <head>
 <meta charset="UTF-8">
-<title>VNC Thug-Hunter</title>
+<title>` + html.EscapeString(reportTitle("VNC Thug-Hunter")+" ["+workspaceTitle()+"]") + `</title>
 <meta name="viewport" content="width=device-width, initial-scale=1.0">
 <style>
 @font-face {
@@ -442,6 +541,19 @@ header h1 {
 }
 [data-theme="light"] .toggle-dark { display: none; }
 [data-theme="dark"] .toggle-light { display: none; }
+.stats .failure {
+	margin-left: 8px;
+	white-space: nowrap;
+}
+.stats input {
+	margin-top: 8px;
+	padding: 4px 8px;
+	width: 280px;
+	background: var(--bg);
+	color: var(--fg);
+	border: 1px solid var(--border);
+	border-radius: 4px;
+}
 .stats {
 	padding: 12px 16px;
 	font-size: 0.95rem;
@@ -584,6 +696,34 @@ button:hover {
 .vnc-info-button:hover .info-pane {
 	display: block;
 }
+
+.card.selected {
+	outline: 2px solid var(--btn-hover);
+}
+.triage {
+	margin-top: 8px;
+	font-size: 0.8rem;
+}
+.triage-buttons {
+	display: flex;
+	gap: 4px;
+	margin: 4px 0;
+}
+.triage-buttons button {
+	border: 1px solid var(--border);
+}
+.triage-note {
+	width: 100%;
+	box-sizing: border-box;
+	padding: 4px 6px;
+	background: var(--bg);
+	color: var(--fg);
+	border: 1px solid var(--border);
+	border-radius: 4px;
+}
+.card[data-triage="confirmed"] .triage-state { color: #ff6b6b; }
+.card[data-triage="false_positive"] .triage-state { color: #06d6a0; }
+.card[data-triage="needs_review"] .triage-state { color: #ffd166; }
 </style>
 </head>
 <body>
@@ -591,7 +731,7 @@ button:hover {
 	<div style="display: flex; align-items: center;">
 		<img class="logo dark-logo" src="data:image/png;base64,` + string(logoDark) + `" alt="Logo Dark">
 		<img class="logo light-logo" src="data:image/png;base64,` + string(logoLight) + `" alt="Logo Light">
-		<h1>Da Thug-Hunting Summary</h1>
+		<h1>` + html.EscapeString(reportTitle("Da Thug-Hunting Summary")) + `</h1>
 	</div>
 	<button class="theme-toggle" onclick="toggleTheme()">
 		<span class="toggle-dark">` + string(darkSVG) + `</span>
@@ -600,23 +740,26 @@ button:hover {
 </header>
 
 <div class="stats">
+	<div><strong>Workspace:</strong> ` + html.EscapeString(workspaceTitle()) + `</div>
 	<div><strong>Report Date:</strong> ` + now.Format("2006-01-02 15:04:05") + `</div>
-	<div><strong>Total Hosts:</strong> ` + strconv.Itoa(totalHosts) + ` |
-	<strong>Succeeded:</strong> ` + strconv.Itoa(totalCount-failedCount-discarededCount) + ` |
-	<strong>Failed:</strong> ` + strconv.Itoa(failedCount) + ` |
-	<strong>Discarded:</strong> ` + strconv.Itoa(discarededCount) + `</div>
-	</div>
+	<div><strong>Total Hosts:</strong> ` + strconv.FormatInt(totalHosts, 10) + ` |
+	<strong>Succeeded:</strong> ` + strconv.Itoa(len(working)) + ` |
+	<strong>Failed:</strong> ` + strconv.Itoa(len(summary.Failed)) + ` |
+	<strong>Discarded:</strong> ` + strconv.Itoa(summary.Discarded) + `</div>
+	<div><strong>Failures by category:</strong>` + breakdown.String() + `</div>
+	<div><strong>Shortcuts:</strong> j/k next/previous, c confirm, f false positive, r needs review, n note</div>
+	<div><input id="geo-filter" type="search" placeholder="Filter by country, city, ASN or org" oninput="filterCards(this.value)"></div>
 </div>
 
 <div class="grid">`)
 
 	for _, r := range working {
 		f.WriteString(fmt.Sprintf(`
-		<div class="card">
-			<h2>%s:%d</h2>
-			<img src="%s" alt="Snapshot of %s" onclick="showOverlay('%s')">
+		<div class="card" data-geo="%s" data-target="%s">
+			<h2>%s</h2>
+			<img src="%s" alt="Snapshot of %s" onclick="showOverlay(this.src)">
 		
-			<div class="vnc-icon-button" onclick="launchVNC('%s', '%d')" title="Connect via VNC">%s</div>
+			<div class="vnc-icon-button" data-ip="%s" data-port="%d" onclick="launchVNC(this.dataset.ip, this.dataset.port)" title="Connect via VNC">%s</div>
 		
 			<div class="vnc-info-button" onmouseover="showInfo(this)" onmouseout="hideInfo(this)">
 				%s
@@ -626,23 +769,37 @@ button:hover {
 					%s
 				</div>
 			</div>
+
+			<div class="triage">
+				<span class="triage-state">not triaged</span>
+				<div class="triage-buttons">
+					<button onclick="setTriage(this, 'confirmed')" title="Confirm (c)">Confirm</button>
+					<button onclick="setTriage(this, 'false_positive')" title="False positive (f)">False positive</button>
+					<button onclick="setTriage(this, 'needs_review')" title="Needs review (r)">Needs review</button>
+				</div>
+				<input class="triage-note" placeholder="Add note, Enter to save (n)" onkeydown="if (event.key === 'Enter') saveNote(this)">
+			</div>
 		</div>`,
-			r.IP, r.Port,
-			r.Filename, r.IP, r.Filename,
-			r.IP, r.Port, string(vncConnectSVG),
+			html.EscapeString(geoSearchText(r)), findings.Key(r.IP, r.Port),
+			html.EscapeString(Target{IP: r.IP, Port: r.Port}.Addr()),
+			html.EscapeString(r.Filename), html.EscapeString(r.IP),
+			html.EscapeString(r.IP), r.Port, string(vncConnectSVG),
 			string(hostInfoSVG),
-			renderInfoText(r.Hostname, r.Location),
+			renderInfoText(r),
 			renderLabels(r.Labels),
 			renderServices(r.Services)))
 	}
 
 	f.WriteString(`</div>
+` + renderFooter() + `
 <div id="overlay" onclick="hideOverlay()">
 	<img id="overlay-img" src="" alt="Fullscreen">
 </div>
 
 <script>
-const CONTROL_SERVER_ADDR = "` + os.Getenv("CONTROL_SERVER_ADDR") + `";
+const CONTROL_SERVER_ADDR = "` + control.Settings().Addr + `";
+const WORKSPACE = "` + cfg.Workspace.Name + `";
+const TOKEN_KEY = "thughunter.control_token." + WORKSPACE;
 function getControlServerURL() {
 	let addr = CONTROL_SERVER_ADDR || "127.0.0.1:7373";
 	if (!addr.startsWith("http")) addr = "http://" + addr;
@@ -656,25 +813,106 @@ function showOverlay(src) {
 	document.getElementById("overlay-img").src = src;
 	document.getElementById("overlay").style.display = "flex";
 }
+function filterCards(text) {
+	const needle = text.trim().toLowerCase();
+	document.querySelectorAll(".card").forEach(card => {
+		card.style.display = !needle || card.dataset.geo.includes(needle) ? "" : "none";
+	});
+}
 function hideOverlay() {
 	document.getElementById("overlay").style.display = "none";
 }
 function launchVNC(ip, port) {
-	const url = getControlServerURL() + '/open-vnc?ip=' + ip + '&port=' + port;
-	fetch(url)
-		.then(r => {
-			if (r.ok) {
-				alert("Launching VNC viewer...");
-			}
-			return;
-		})
-		.catch(err => {
-			console.warn("Fetch to control server failed (VNC may still launch):", err);
+	api("/open-vnc", {ip: ip, port: String(port)})
+		.then(() => alert("Launching VNC viewer..."))
+		.catch(err => alert("Could not launch VNC viewer: " + err.message));
+}
+function controlToken(ask) {
+	let token = localStorage.getItem(TOKEN_KEY);
+	if (!token && ask) {
+		token = (prompt("Control server token (see control.token):") || "").trim();
+		if (token) localStorage.setItem(TOKEN_KEY, token);
+	}
+	return token;
+}
+function api(path, body) {
+	const token = controlToken(!!body);
+	if (!token) return Promise.reject(new Error("no control token"));
+	const opts = {headers: {"Authorization": "Bearer " + token, "` + control.WorkspaceHeader + `": WORKSPACE}};
+	if (body) {
+		opts.method = "POST";
+		opts.headers["Content-Type"] = "application/json";
+		opts.body = JSON.stringify(body);
+	}
+	return fetch(getControlServerURL() + path, opts).then(r => {
+		if (r.status === 401) localStorage.removeItem(TOKEN_KEY);
+		if (!r.ok) return r.text().then(t => { throw new Error(t.trim()); });
+		return r.status === 204 ? null : r.json();
+	});
+}
+function renderTriage(card, finding) {
+	const state = card.querySelector(".triage-state");
+	const status = finding.Triage || "new";
+	card.dataset.triage = status;
+	const last = (finding.Notes || []).filter(n => !n.Text.startsWith("status ")).pop();
+	state.textContent = status.replace("_", " ") + (last ? " | " + last.Author + ": " + last.Text : "");
+}
+function triageRequest(card, body) {
+	body.target = card.dataset.target;
+	api("/api/triage", body)
+		.then(f => renderTriage(card, f))
+		.catch(err => alert("Triage failed: " + err.message));
+}
+function setTriage(el, status) {
+	triageRequest(el.closest(".card"), {triage: status});
+}
+function saveNote(input) {
+	if (!input.value.trim()) return;
+	triageRequest(input.closest(".card"), {note: input.value});
+	input.value = "";
+	input.blur();
+}
+function loadTriage() {
+	api("/api/findings").then(list => {
+		const byTarget = {};
+		list.forEach(f => { byTarget[f.IP + ":" + f.Port] = f; });
+		document.querySelectorAll(".card").forEach(card => {
+			const f = byTarget[card.dataset.target];
+			if (f) renderTriage(card, f);
 		});
+	}).catch(err => console.warn("Could not load triage state from control server:", err));
+}
+function visibleCards() {
+	return Array.from(document.querySelectorAll(".card")).filter(c => c.style.display !== "none");
+}
+function selectCard(delta) {
+	const cards = visibleCards();
+	if (!cards.length) return;
+	const cur = document.querySelector(".card.selected");
+	let i = cards.indexOf(cur) + delta;
+	i = Math.max(0, Math.min(cards.length - 1, i));
+	if (cur) cur.classList.remove("selected");
+	cards[i].classList.add("selected");
+	cards[i].scrollIntoView({block: "center", behavior: "smooth"});
 }
 document.addEventListener("keydown", e => {
-	if (e.key === "Escape") hideOverlay();
+	if (e.key === "Escape") {
+		hideOverlay();
+		if (document.activeElement) document.activeElement.blur();
+		return;
+	}
+	if (e.target.tagName === "INPUT" || e.ctrlKey || e.metaKey || e.altKey) return;
+	const card = document.querySelector(".card.selected");
+	switch (e.key) {
+	case "j": selectCard(1); break;
+	case "k": selectCard(-1); break;
+	case "c": if (card) triageRequest(card, {triage: "confirmed"}); break;
+	case "f": if (card) triageRequest(card, {triage: "false_positive"}); break;
+	case "r": if (card) triageRequest(card, {triage: "needs_review"}); break;
+	case "n": if (card) { e.preventDefault(); card.querySelector(".triage-note").focus(); } break;
+	}
 });
+loadTriage();
 document.querySelectorAll(".card img").forEach(img => {
 	img.onclick = e => {
 		e.stopPropagation();
@@ -685,9 +923,10 @@ document.querySelectorAll(".card img").forEach(img => {
 </body>
 </html>`)
 
-	err = openFile(path)
-	if err != nil {
-		fmt.Println("Failed to open generated file:", err)
+	if open {
+		if err := openFile(path); err != nil {
+			fmt.Println("Failed to open generated file:", err)
+		}
 	}
 
 	fmt.Println("HTML summary saved")
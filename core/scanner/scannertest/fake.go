@@ -0,0 +1,97 @@
+// core/scanner/scannertest/fake.go
+
+// Package scannertest provides a scripted scanner.Capturer for tests.
+package scannertest
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"sync"
+	"time"
+
+	"smuggr.xyz/thughunter/core/scanner"
+)
+
+// Fake writes fixture PNGs or returns scripted errors, keyed by
+// Target.String(). Unscripted targets get Default or a test pattern.
+type Fake struct {
+	Errors  map[string]error
+	Images  map[string]image.Image
+	Default image.Image
+	// Delay is waited before each capture to simulate slow hosts.
+	Delay time.Duration
+
+	mu    sync.Mutex
+	calls []scanner.Target
+}
+
+func (f *Fake) Capture(ctx context.Context, t scanner.Target, output string) error {
+	f.mu.Lock()
+	f.calls = append(f.calls, t)
+	f.mu.Unlock()
+
+	if f.Delay > 0 {
+		select {
+		case <-time.After(f.Delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if err, ok := f.Errors[t.String()]; ok {
+		return err
+	}
+	img, ok := f.Images[t.String()]
+	if !ok {
+		img = f.Default
+	}
+	if img == nil {
+		img = Pattern(320, 240)
+	}
+	return WritePNG(output, img)
+}
+
+// Calls returns the targets captured so far, in the order they started.
+func (f *Fake) Calls() []scanner.Target {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]scanner.Target(nil), f.calls...)
+}
+
+// Blank returns a single-colour image. Use a screen-like size, smaller
+// PNGs fail the scanner's 1 KiB size check.
+func Blank(w, h int, c color.Color) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+// Pattern returns a gradient the scanner keeps.
+func Pattern(w, h int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{uint8(x * 7), uint8(y * 13), uint8((x ^ y) * 29), 0xff})
+		}
+	}
+	return img
+}
+
+// WritePNG encodes img to path.
+func WritePNG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,55 @@
+// core/scanner/snapshots.go
+package scanner
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Snapshots are named ip_port.png with unsafe characters replaced by
+// dashes; index.json maps the names back to targets.
+
+const snapshotIndexFile = "index.json"
+
+// SnapshotEntry maps one snapshot file back to its target.
+type SnapshotEntry struct {
+	File      string `json:"file"`
+	IP        string `json:"ip"`
+	Port      int    `json:"port"`
+	Discarded bool   `json:"discarded,omitempty"`
+}
+
+func snapshotName(ip string, port int) string {
+	safe := strings.Map(func(r rune) rune {
+		switch {
+		case r >= '0' && r <= '9', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '.':
+			return r
+		}
+		return '-'
+	}, ip)
+	return fmt.Sprintf("%s_%d.png", safe, port)
+}
+
+// writeSnapshotIndex writes snapshotDir/index.json.
+func writeSnapshotIndex(snapshotDir string, out scanOutcome) error {
+	entries := make([]SnapshotEntry, 0, len(out.Working)+len(out.Blank))
+	for _, w := range out.Working {
+		entries = append(entries, SnapshotEntry{File: snapshotName(w.IP, w.Port), IP: w.IP, Port: w.Port})
+	}
+	for _, b := range out.Blank {
+		entries = append(entries, SnapshotEntry{
+			File:      filepath.ToSlash(filepath.Join("discarded", snapshotName(b.IP, b.Port))),
+			IP:        b.IP,
+			Port:      b.Port,
+			Discarded: true,
+		})
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(snapshotDir, snapshotIndexFile), data, 0644)
+}
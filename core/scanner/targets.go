@@ -0,0 +1,230 @@
+// core/scanner/targets.go
+package scanner
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"smuggr.xyz/thughunter/common/models"
+	"smuggr.xyz/thughunter/core/datastore"
+	"smuggr.xyz/thughunter/core/filter"
+	"smuggr.xyz/thughunter/core/scope"
+)
+
+type TargetSelection struct {
+	Filter       string
+	File         string
+	SinceLastRun bool
+	Host         string
+}
+
+func (s TargetSelection) String() string {
+	var parts []string
+	if s.Filter != "" {
+		parts = append(parts, "filter "+s.Filter)
+	}
+	if s.File != "" {
+		parts = append(parts, "file "+s.File)
+	}
+	if s.SinceLastRun {
+		parts = append(parts, "since last run")
+	}
+	if s.Host != "" {
+		parts = append(parts, "host "+s.Host)
+	}
+	if len(parts) == 0 {
+		return "all"
+	}
+	return strings.Join(parts, ", ")
+}
+
+const targetBatchSize = 1000
+
+// hasVNC selects hosts with a VNC service via host_services.
+const hasVNC = "EXISTS (SELECT 1 FROM host_services WHERE host_services.ip = hosts.ip AND host_services.name = 'VNC')"
+
+// StreamTargets passes the hosts selected by sel to fn in batches.
+func StreamTargets(sel TargetSelection, fn func([]models.Host) error) error {
+	if sel.Host != "" {
+		hosts, err := resolveSingleHost(sel.Host)
+		if err != nil {
+			return err
+		}
+		return fn(hosts)
+	}
+
+	tx, sc, err := targetQuery(sel)
+	if err != nil {
+		return err
+	}
+	var batch []models.Host
+	return tx.FindInBatches(&batch, targetBatchSize, func(_ *gorm.DB, _ int) error {
+		if sc == nil {
+			return fn(batch)
+		}
+		var in []models.Host
+		for _, h := range batch {
+			if sc.Contains(h.IP) {
+				in = append(in, h)
+			}
+		}
+		if len(in) == 0 {
+			return nil
+		}
+		return fn(in)
+	}).Error
+}
+
+// CountTargets counts the hosts StreamTargets would pass on.
+func CountTargets(sel TargetSelection) (int, error) {
+	if sel.Host != "" {
+		hosts, err := resolveSingleHost(sel.Host)
+		return len(hosts), err
+	}
+
+	tx, sc, err := targetQuery(sel)
+	if err != nil {
+		return 0, err
+	}
+	if sc == nil {
+		var n int64
+		err := tx.Count(&n).Error
+		return int(n), err
+	}
+	// Scope files are matched in Go, so only the addresses are read.
+	n := 0
+	var batch []models.Host
+	err = tx.Select("ip").FindInBatches(&batch, targetBatchSize, func(_ *gorm.DB, _ int) error {
+		for _, h := range batch {
+			if sc.Contains(h.IP) {
+				n++
+			}
+		}
+		return nil
+	}).Error
+	return n, err
+}
+
+func targetQuery(sel TargetSelection) (*gorm.DB, *scope.Scope, error) {
+	q, err := filter.Parse(sel.Filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	tx := q.Apply(datastore.DB.Model(&models.Host{})).Where(hasVNC)
+
+	if sel.SinceLastRun {
+		var last models.ScanRun
+		r := datastore.DB.Where("finished_at IS NOT NULL").Order("started_at desc").Limit(1).Find(&last)
+		if r.Error != nil {
+			return nil, nil, r.Error
+		}
+		if r.RowsAffected > 0 {
+			tx = tx.Where("first_seen > ?", last.StartedAt)
+		}
+	}
+
+	var sc *scope.Scope
+	if sel.File != "" {
+		if sc, err = scope.Load(sel.File); err != nil {
+			return nil, nil, err
+		}
+	}
+	return tx, sc, nil
+}
+
+func resolveSingleHost(target string) ([]models.Host, error) {
+	ip, port := target, 0
+	if host, portStr, err := net.SplitHostPort(target); err == nil {
+		p, err := strconv.Atoi(portStr)
+		if err != nil || p <= 0 || p > 65535 {
+			return nil, fmt.Errorf("invalid port in %q", target)
+		}
+		ip, port = host, p
+	}
+	if net.ParseIP(ip) == nil {
+		return nil, fmt.Errorf("invalid IP %q", ip)
+	}
+
+	var h models.Host
+	r := datastore.DB.Where("ip = ?", ip).Limit(1).Find(&h)
+	if r.Error != nil {
+		return nil, r.Error
+	}
+	if r.RowsAffected == 0 {
+		if port == 0 {
+			return nil, fmt.Errorf("%s is not in the database, give a port to test it anyway", ip)
+		}
+		h = models.Host{IP: ip, Services: models.JSONServiceMap{}}
+	}
+	if port != 0 {
+		services := models.JSONServiceMap{}
+		for k, v := range h.Services {
+			services[k] = v
+		}
+		services["VNC"] = port
+		h.Services = services
+	}
+	if _, ok := h.Services["VNC"]; !ok {
+		return nil, fmt.Errorf("%s has no known VNC service, give a port to test it anyway", ip)
+	}
+	return []models.Host{h}, nil
+}
+
+func printTargets(hosts []models.Host) {
+	for _, h := range hosts {
+		fmt.Printf("%s:%d %s\n", h.IP, h.Services["VNC"], h.Hostname)
+	}
+}
+
+func askTargetSelection(r *bufio.Reader) (TargetSelection, bool) {
+	fmt.Println("Select scan targets:")
+	fmt.Println("1) All hosts with VNC")
+	fmt.Println("2) Hosts matching a filter expression")
+	fmt.Println("3) IPs/CIDRs from a file")
+	fmt.Println("4) Hosts added since the last scan")
+	fmt.Println("5) Single host retest")
+	fmt.Print("Select: ")
+	choiceStr, _ := r.ReadString('\n')
+	choice, _ := strconv.Atoi(strings.TrimSpace(choiceStr))
+
+	var sel TargetSelection
+	var err error
+	switch choice {
+	case 1:
+	case 2:
+		fmt.Print("Filter, e.g. location:\"DE\" port:5900-5910: ")
+		sel.Filter = readLine(r)
+		_, err = filter.Parse(sel.Filter)
+	case 3:
+		fmt.Print("Path to file with one IP or CIDR per line: ")
+		sel.File = readLine(r)
+		_, err = scope.Load(sel.File)
+	case 4:
+		sel.SinceLastRun = true
+	case 5:
+		fmt.Print("Host (ip or ip:port): ")
+		sel.Host = readLine(r)
+		if sel.Host == "" {
+			err = errors.New("no host given")
+		}
+	default:
+		fmt.Println("Invalid selection")
+		return sel, false
+	}
+	if err != nil {
+		fmt.Println("Invalid target selection:", err)
+		return sel, false
+	}
+	return sel, true
+}
+
+func readLine(r *bufio.Reader) string {
+	s, _ := r.ReadString('\n')
+	return strings.TrimSpace(s)
+}
@@ -0,0 +1,98 @@
+// core/scheduler/jobs.go
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"smuggr.xyz/thughunter/core/config"
+	"smuggr.xyz/thughunter/core/queries"
+	"smuggr.xyz/thughunter/core/scanner"
+	"smuggr.xyz/thughunter/core/scope"
+	"smuggr.xyz/thughunter/core/scraper"
+)
+
+func DefaultJobs(cfg config.Schedule) ([]*Job, error) {
+	var jobs []*Job
+
+	if spec := cfg.Update; spec != "" {
+		selection := cfg.UpdateQueries
+		if _, err := queries.Select(selection); err != nil {
+			return nil, fmt.Errorf("schedule.update_queries: %w", err)
+		}
+		j, err := NewJob("update", spec, func() (string, error) {
+			return runUpdate(selection)
+		})
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, j)
+	}
+
+	if spec := cfg.Scan; spec != "" {
+		scopePath := cfg.ScopePath
+		if _, err := scope.Load(scopePath); err != nil {
+			return nil, fmt.Errorf("scheduled scans need a scope file: %w", err)
+		}
+		j, err := NewJob("scan", spec, func() (string, error) {
+			return runScopedScan(scopePath)
+		})
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, j)
+	}
+
+	if len(jobs) == 0 {
+		return nil, errors.New("no jobs configured, set SCHEDULE_UPDATE and/or SCHEDULE_SCAN")
+	}
+	return jobs, nil
+}
+
+func runUpdate(selection string) (string, error) {
+	selected, err := queries.Select(selection)
+	if err != nil {
+		return "", err
+	}
+	totalNew, totalUpdated, truncated := 0, 0, 0
+	var failed []string
+	session, err := scraper.NewSession()
+	if err != nil {
+		return "", err
+	}
+	defer session.Close()
+	for i := range selected {
+		st, err := queries.Run(session, &selected[i], false)
+		totalNew += st.New
+		totalUpdated += st.Updated
+		if st.StoppedBy != "" {
+			truncated++
+		}
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", selected[i].Name, err))
+		}
+	}
+	summary := fmt.Sprintf("%d queries: %d new, %d updated, %d stopped early", len(selected), totalNew, totalUpdated, truncated)
+	if len(failed) > 0 {
+		return "", fmt.Errorf("%s, %d failed (%s)", summary, len(failed), strings.Join(failed, "; "))
+	}
+	return summary, nil
+}
+
+func runScopedScan(scopePath string) (string, error) {
+	summary, err := scanner.Scan(scanner.ScanOptions{
+		HTML:    true,
+		Notify:  true,
+		Targets: scanner.TargetSelection{File: scopePath},
+	})
+	if err != nil {
+		return "", err
+	}
+	msg := fmt.Sprintf("%d targets: %d working, %d failed, %d discarded (%s)",
+		summary.Targets, len(summary.Working), len(summary.Failed), summary.Discarded, summary.Dir)
+	if b := summary.Breakdown(); len(b) > 0 {
+		msg += "; " + scanner.FormatBreakdown(b)
+	}
+	return msg, nil
+}
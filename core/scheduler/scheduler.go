@@ -0,0 +1,220 @@
+// core/scheduler/scheduler.go
+package scheduler
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"smuggr.xyz/thughunter/common/models"
+	"smuggr.xyz/thughunter/core/datastore"
+)
+
+const (
+	StatusRunning = "running"
+	StatusOK      = "ok"
+	StatusFailed  = "failed"
+	StatusSkipped = "skipped"
+)
+
+type Job struct {
+	Name string
+	Spec string
+	Run  func() (string, error)
+
+	schedule cron.Schedule
+	running  sync.Mutex
+	next     time.Time
+}
+
+type Scheduler struct {
+	mu   sync.Mutex
+	jobs []*Job
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func NewJob(name, spec string, run func() (string, error)) (*Job, error) {
+	sched, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, fmt.Errorf("job %s: invalid schedule %q: %w", name, spec, err)
+	}
+	return &Job{Name: name, Spec: spec, Run: run, schedule: sched}, nil
+}
+
+func New(jobs []*Job) *Scheduler {
+	return &Scheduler{jobs: jobs, stop: make(chan struct{})}
+}
+
+func (s *Scheduler) Start() {
+	now := time.Now()
+	interrupted := closeInterrupted(now)
+	s.mu.Lock()
+	for _, j := range s.jobs {
+		if cut, ok := interrupted[j.Name]; ok {
+			fmt.Printf("[scheduler] %s was interrupted during its run at %s, running it again\n", j.Name, cut.Format("2006-01-02 15:04"))
+			s.fire(j, cut)
+		} else if missed, ok := missedRun(j, now); ok {
+			fmt.Printf("[scheduler] %s missed its run at %s, catching up\n", j.Name, missed.Format("2006-01-02 15:04"))
+			s.fire(j, missed)
+		}
+		j.next = j.schedule.Next(now)
+		fmt.Printf("[scheduler] %s (%s) next run at %s\n", j.Name, j.Spec, j.next.Format("2006-01-02 15:04"))
+	}
+	s.mu.Unlock()
+
+	go s.loop()
+}
+
+func (s *Scheduler) Stop() {
+	close(s.stop)
+	s.wg.Wait()
+}
+
+func (s *Scheduler) loop() {
+	for {
+		s.mu.Lock()
+		now := time.Now()
+		var soonest time.Time
+		for _, j := range s.jobs {
+			if !j.next.After(now) {
+				s.fire(j, j.next)
+				j.next = j.schedule.Next(now)
+			}
+			if soonest.IsZero() || j.next.Before(soonest) {
+				soonest = j.next
+			}
+		}
+		s.mu.Unlock()
+
+		if soonest.IsZero() {
+			<-s.stop
+			return
+		}
+		timer := time.NewTimer(time.Until(soonest))
+		select {
+		case <-timer.C:
+		case <-s.stop:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+func (s *Scheduler) fire(j *Job, scheduledFor time.Time) {
+	run := models.JobRun{Job: j.Name, ScheduledFor: scheduledFor, StartedAt: time.Now()}
+
+	if !j.running.TryLock() {
+		finished := run.StartedAt
+		run.FinishedAt = &finished
+		run.Status = StatusSkipped
+		run.Message = "previous run still in progress"
+		fmt.Printf("[scheduler] %s skipped: %s\n", j.Name, run.Message)
+		saveRun(&run)
+		return
+	}
+
+	run.Status = StatusRunning
+	saveRun(&run)
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer j.running.Unlock()
+
+		fmt.Printf("[scheduler] %s started\n", j.Name)
+		msg, err := j.Run()
+		finished := time.Now()
+		run.FinishedAt = &finished
+		run.Status = StatusOK
+		run.Message = msg
+		if err != nil {
+			run.Status = StatusFailed
+			run.Message = err.Error()
+		}
+		fmt.Printf("[scheduler] %s finished (%s): %s\n", j.Name, run.Status, run.Message)
+		saveRun(&run)
+	}()
+}
+
+const interruptedMessage = "interrupted, the daemon stopped before the run finished"
+
+// closeInterrupted fails the runs a crash left running and returns the
+// latest interrupted fire time of each job.
+func closeInterrupted(now time.Time) map[string]time.Time {
+	var stale []models.JobRun
+	if err := datastore.DB.Where("status = ?", StatusRunning).Find(&stale).Error; err != nil {
+		fmt.Printf("[!] Failed to look for interrupted runs: %v\n", err)
+		return nil
+	}
+	cut := make(map[string]time.Time)
+	for _, run := range stale {
+		run.Status = StatusFailed
+		run.Message = interruptedMessage
+		run.FinishedAt = &now
+		saveRun(&run)
+		if run.ScheduledFor.After(cut[run.Job]) {
+			cut[run.Job] = run.ScheduledFor
+		}
+	}
+	return cut
+}
+
+// missedRun returns the first fire after the job's last run or, when it
+// never ran, after the daemon first scheduled it.
+func missedRun(j *Job, now time.Time) (time.Time, bool) {
+	var last models.JobRun
+	r := datastore.DB.Where("job = ?", j.Name).Order("scheduled_for desc, id desc").Limit(1).Find(&last)
+	if r.Error != nil {
+		return time.Time{}, false
+	}
+	since := last.ScheduledFor
+	if r.RowsAffected == 0 {
+		since = firstScheduled(j, now)
+	}
+	missed := j.schedule.Next(since)
+	return missed, missed.Before(now)
+}
+
+func firstScheduled(j *Job, now time.Time) time.Time {
+	m := models.Marker{Name: "scheduler.first." + j.Name, SetAt: now}
+	if err := datastore.DB.Where(models.Marker{Name: m.Name}).FirstOrCreate(&m).Error; err != nil {
+		fmt.Printf("[!] Failed to record when %s was first scheduled: %v\n", j.Name, err)
+		return now
+	}
+	return m.SetAt
+}
+
+func saveRun(run *models.JobRun) {
+	if err := datastore.DB.Save(run).Error; err != nil {
+		fmt.Printf("[!] Failed to record %s run: %v\n", run.Job, err)
+	}
+}
+
+type JobStatus struct {
+	Name    string
+	Spec    string
+	Next    time.Time
+	Running bool
+	History []models.JobRun
+}
+
+func (s *Scheduler) Status(historyLimit int) []JobStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out []JobStatus
+	for _, j := range s.jobs {
+		st := JobStatus{Name: j.Name, Spec: j.Spec, Next: j.next}
+		if j.running.TryLock() {
+			j.running.Unlock()
+		} else {
+			st.Running = true
+		}
+		datastore.DB.Where("job = ?", j.Name).Order("started_at desc").Limit(historyLimit).Find(&st.History)
+		out = append(out, st)
+	}
+	return out
+}
@@ -0,0 +1,145 @@
+package scheduler
+
+import (
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"smuggr.xyz/thughunter/common/models"
+	"smuggr.xyz/thughunter/core/datastore"
+)
+
+// hourly fires at minute 0, so a test never waits for a regular fire.
+const hourly = "0 * * * *"
+
+func newDB(t *testing.T) {
+	t.Helper()
+	datastore.Initialize(filepath.Join(t.TempDir(), "scheduler.db"))
+}
+
+type counter struct{ n atomic.Int32 }
+
+func (c *counter) job(t *testing.T, name string, err error) *Job {
+	t.Helper()
+	j, err2 := NewJob(name, hourly, func() (string, error) {
+		c.n.Add(1)
+		return "done", err
+	})
+	if err2 != nil {
+		t.Fatal(err2)
+	}
+	return j
+}
+
+func runs(t *testing.T, job string) []models.JobRun {
+	t.Helper()
+	var out []models.JobRun
+	if err := datastore.DB.Where("job = ?", job).Order("id").Find(&out).Error; err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func startStop(jobs ...*Job) {
+	s := New(jobs)
+	s.Start()
+	s.Stop()
+}
+
+func TestFirstStartDoesNotCatchUp(t *testing.T) {
+	newDB(t)
+	var c counter
+	startStop(c.job(t, "update", nil))
+	if c.n.Load() != 0 || len(runs(t, "update")) != 0 {
+		t.Errorf("first start ran the job %d times", c.n.Load())
+	}
+
+	var m models.Marker
+	if err := datastore.DB.First(&m, "name = ?", "scheduler.first.update").Error; err != nil {
+		t.Fatal("first start was not recorded:", err)
+	}
+}
+
+func TestCatchUpBeforeFirstRun(t *testing.T) {
+	newDB(t)
+	// The daemon first scheduled the job three hours ago and went down
+	// before it ever fired.
+	datastore.DB.Create(&models.Marker{Name: "scheduler.first.scan", SetAt: time.Now().Add(-3 * time.Hour)})
+
+	var c counter
+	startStop(c.job(t, "scan", nil))
+	got := runs(t, "scan")
+	if c.n.Load() != 1 || len(got) != 1 || got[0].Status != StatusOK {
+		t.Fatalf("ran %d times, runs %+v", c.n.Load(), got)
+	}
+	if !got[0].ScheduledFor.Before(time.Now().Add(-time.Hour)) {
+		t.Errorf("caught up %s, want the first fire after the marker", got[0].ScheduledFor)
+	}
+}
+
+func TestCatchUpAfterLastRun(t *testing.T) {
+	newDB(t)
+	last := time.Now().Add(-2 * time.Hour).Truncate(time.Hour)
+	done := last.Add(time.Minute)
+	datastore.DB.Create(&models.JobRun{Job: "scan", ScheduledFor: last, StartedAt: last, FinishedAt: &done, Status: StatusOK})
+
+	var c counter
+	startStop(c.job(t, "scan", nil))
+	got := runs(t, "scan")
+	if c.n.Load() != 1 || len(got) != 2 || !got[1].ScheduledFor.Equal(last.Add(time.Hour)) {
+		t.Fatalf("ran %d times, runs %+v", c.n.Load(), got)
+	}
+
+	// The catch-up counts as a run, so the next start has nothing to do
+	// until the following fire is due.
+	var again counter
+	datastore.DB.Model(&models.JobRun{}).Where("id = ?", got[1].ID).Update("scheduled_for", time.Now().Truncate(time.Hour))
+	startStop(again.job(t, "scan", nil))
+	if again.n.Load() != 0 {
+		t.Errorf("up to date job ran %d times", again.n.Load())
+	}
+}
+
+func TestInterruptedRunIsClosedAndRetried(t *testing.T) {
+	newDB(t)
+	cut := time.Now().Add(-30 * time.Minute).Truncate(time.Minute)
+	datastore.DB.Create(&models.JobRun{Job: "update", ScheduledFor: cut, StartedAt: cut, Status: StatusRunning})
+
+	var c counter
+	startStop(c.job(t, "update", errors.New("censys down")))
+	got := runs(t, "update")
+	if len(got) != 2 {
+		t.Fatalf("runs %+v", got)
+	}
+	if got[0].Status != StatusFailed || got[0].Message != interruptedMessage || got[0].FinishedAt == nil {
+		t.Errorf("interrupted run left as %s %q", got[0].Status, got[0].Message)
+	}
+	if c.n.Load() != 1 || !got[1].ScheduledFor.Equal(cut) || got[1].Status != StatusFailed || got[1].Message != "censys down" {
+		t.Errorf("rerun %+v", got[1])
+	}
+}
+
+func TestOverlappingFireIsSkipped(t *testing.T) {
+	newDB(t)
+	release := make(chan struct{})
+	j, err := NewJob("scan", hourly, func() (string, error) {
+		<-release
+		return "done", nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New([]*Job{j})
+	now := time.Now()
+	s.fire(j, now)
+	s.fire(j, now.Add(time.Hour))
+	close(release)
+	s.wg.Wait()
+
+	got := runs(t, "scan")
+	if len(got) != 2 || got[0].Status != StatusOK || got[1].Status != StatusSkipped {
+		t.Errorf("runs %+v", got)
+	}
+}
@@ -0,0 +1,60 @@
+// core/scheduler/server.go
+package scheduler
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+
+	"smuggr.xyz/thughunter/core/control"
+)
+
+var jobsPage = template.Must(template.New("jobs").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="UTF-8">
+<title>ThugHunter Jobs</title>
+<style>
+body { font-family: system-ui, sans-serif; background: #1e1e1e; color: #fff; margin: 16px; }
+table { border-collapse: collapse; margin-bottom: 24px; }
+td, th { border: 1px solid #444; padding: 4px 8px; font-size: 0.85rem; text-align: left; }
+.failed { color: #ff6b6b; }
+.skipped { color: #ffd166; }
+.ok { color: #06d6a0; }
+</style>
+</head>
+<body>
+<h1>Scheduled Jobs</h1>
+{{range .}}
+<h2>{{.Name}} <small>({{.Spec}})</small></h2>
+<p><strong>Next run:</strong> {{.Next.Format "2006-01-02 15:04:05"}}{{if .Running}} | <strong>running now</strong>{{end}}</p>
+<table>
+<tr><th>Scheduled</th><th>Started</th><th>Finished</th><th>Status</th><th>Message</th></tr>
+{{range .History}}
+<tr>
+	<td>{{.ScheduledFor.Format "2006-01-02 15:04"}}</td>
+	<td>{{.StartedAt.Format "2006-01-02 15:04:05"}}</td>
+	<td>{{if .FinishedAt}}{{.FinishedAt.Format "2006-01-02 15:04:05"}}{{end}}</td>
+	<td class="{{.Status}}">{{.Status}}</td>
+	<td>{{.Message}}</td>
+</tr>
+{{end}}
+</table>
+{{end}}
+</body>
+</html>`))
+
+func (s *Scheduler) RegisterHandlers() {
+	http.HandleFunc("GET /jobs", control.Page(func(w http.ResponseWriter, r *http.Request) {
+		status := s.Status(20)
+		if r.URL.Query().Get("format") == "json" {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(status)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := jobsPage.Execute(w, status); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}))
+}
@@ -0,0 +1,80 @@
+// core/scope/scope.go
+package scope
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+type Scope struct {
+	ips   map[string]bool
+	nets  []*net.IPNet
+	Count int
+}
+
+func Load(path string) (*Scope, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sc := &Scope{ips: make(map[string]bool)}
+	lines := bufio.NewScanner(f)
+	lineNo := 0
+	for lines.Scan() {
+		lineNo++
+		entry := strings.TrimSpace(lines.Text())
+		if i := strings.Index(entry, "#"); i >= 0 {
+			entry = strings.TrimSpace(entry[:i])
+		}
+		if entry == "" {
+			continue
+		}
+		if err := sc.add(entry); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNo, err)
+		}
+	}
+	if err := lines.Err(); err != nil {
+		return nil, err
+	}
+	return sc, nil
+}
+
+func (s *Scope) add(entry string) error {
+	if strings.Contains(entry, "/") {
+		_, n, err := net.ParseCIDR(entry)
+		if err != nil {
+			return fmt.Errorf("invalid CIDR %q", entry)
+		}
+		s.nets = append(s.nets, n)
+		s.Count++
+		return nil
+	}
+	ip := net.ParseIP(entry)
+	if ip == nil {
+		return fmt.Errorf("invalid IP %q", entry)
+	}
+	s.ips[ip.String()] = true
+	s.Count++
+	return nil
+}
+
+func (s *Scope) Contains(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	if s.ips[ip.String()] {
+		return true
+	}
+	for _, n := range s.nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
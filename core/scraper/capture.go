@@ -0,0 +1,118 @@
+// core/scraper/capture.go
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+const captureSettle = 10 * time.Second
+
+type capturedResponse struct {
+	URL  string
+	Body []byte
+	At   time.Time
+}
+
+// capture records the JSON responses the Censys web app fetches while the
+// updater drives the browser.
+type capture struct {
+	ctx      context.Context
+	pattern  string
+	mu       sync.Mutex
+	pending  map[network.RequestID]string
+	inflight int
+	bodies   []capturedResponse
+}
+
+func startCapture(ctx context.Context, pattern string) *capture {
+	c := &capture{ctx: ctx, pattern: pattern, pending: make(map[network.RequestID]string)}
+	chromedp.ListenTarget(ctx, func(ev interface{}) {
+		switch e := ev.(type) {
+		case *network.EventResponseReceived:
+			if e.Type != network.ResourceTypeXHR && e.Type != network.ResourceTypeFetch {
+				return
+			}
+			if !strings.Contains(e.Response.MimeType, "json") || !strings.Contains(e.Response.URL, c.pattern) {
+				return
+			}
+			c.mu.Lock()
+			c.pending[e.RequestID] = e.Response.URL
+			c.mu.Unlock()
+		case *network.EventLoadingFinished:
+			c.mu.Lock()
+			url, ok := c.pending[e.RequestID]
+			delete(c.pending, e.RequestID)
+			if ok {
+				c.inflight++
+			}
+			c.mu.Unlock()
+			if ok {
+				// Listeners must not block, the body is fetched separately.
+				go c.fetch(e.RequestID, url)
+			}
+		}
+	})
+	return c
+}
+
+func (c *capture) fetch(id network.RequestID, url string) {
+	var body []byte
+	err := chromedp.Run(c.ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		body, err = network.GetResponseBody(id).Do(ctx)
+		return err
+	}))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.inflight--
+	if err != nil {
+		fmt.Printf("[!] Failed to capture %s: %v\n", url, err)
+		return
+	}
+	c.bodies = append(c.bodies, capturedResponse{URL: url, Body: body, At: time.Now()})
+}
+
+// take waits for bodies still being fetched and returns the responses
+// captured since the previous call.
+func (c *capture) take() []capturedResponse {
+	deadline := time.Now().Add(captureSettle)
+	for {
+		c.mu.Lock()
+		if c.inflight == 0 || time.Now().After(deadline) {
+			out := c.bodies
+			c.bodies = nil
+			c.mu.Unlock()
+			return out
+		}
+		c.mu.Unlock()
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// saveResponses keeps the raw responses next to the imported hosts so they
+// can be inspected or decoded again later.
+func saveResponses(dir string, page int, responses []capturedResponse) error {
+	if dir == "" || len(responses) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	for i, r := range responses {
+		name := fmt.Sprintf("%s_p%d_%d.json", r.At.Format("2006-01-02_15-04-05"), page, i+1)
+		if err := os.WriteFile(filepath.Join(dir, name), r.Body, 0600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+// core/scraper/credits.go
+package scraper
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm/clause"
+
+	"smuggr.xyz/thughunter/common/models"
+	"smuggr.xyz/thughunter/core/datastore"
+)
+
+var ErrCreditsExhausted = errors.New("credit budget exhausted")
+
+// Usage is the credits spent in the current day and calendar month.
+type Usage struct {
+	Day   int
+	Month int
+}
+
+// QueryCredits is the ledger total of one query in one workspace.
+type QueryCredits struct {
+	Workspace string
+	Query     string
+	Pages     int
+	Credits   int
+}
+
+// NormalizeQuery folds a query to its cache key: whitespace outside quotes
+// is collapsed and the boolean keywords are lowercased, quoted values are
+// kept as they are.
+func NormalizeQuery(q string) string {
+	var words []string
+	var cur strings.Builder
+	quoted := false
+	flush := func() {
+		if cur.Len() == 0 {
+			return
+		}
+		w := cur.String()
+		switch strings.ToLower(w) {
+		case "and", "or", "not":
+			w = strings.ToLower(w)
+		}
+		words = append(words, w)
+		cur.Reset()
+	}
+	for _, r := range strings.TrimSpace(q) {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			cur.WriteRune(r)
+		case !quoted && (r == ' ' || r == '\t' || r == '\n' || r == '\r'):
+			flush()
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	flush()
+	return strings.Join(words, " ")
+}
+
+// CreditUsage sums the ledger for the day and month containing now, over
+// all workspaces.
+func CreditUsage(now time.Time) (Usage, error) {
+	var u Usage
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	if err := datastore.Ledger.Model(&models.CreditEntry{}).Where("spent_at >= ?", day).
+		Select("COALESCE(SUM(credits), 0)").Scan(&u.Day).Error; err != nil {
+		return u, err
+	}
+	err := datastore.Ledger.Model(&models.CreditEntry{}).Where("spent_at >= ?", month).
+		Select("COALESCE(SUM(credits), 0)").Scan(&u.Month).Error
+	return u, err
+}
+
+// Ledger totals the credits spent per workspace and query since the given
+// time, most expensive first.
+func Ledger(since time.Time) ([]QueryCredits, error) {
+	var out []QueryCredits
+	err := datastore.Ledger.Model(&models.CreditEntry{}).
+		Select("workspace, query_key AS query, COUNT(*) AS pages, SUM(credits) AS credits").
+		Where("spent_at >= ?", since).
+		Group("workspace, query_key").
+		Order("credits desc").
+		Scan(&out).Error
+	return out, err
+}
+
+// checkBudget fails when one more page would go over the daily or monthly
+// credits.
+func checkBudget() error {
+	if cfg.DailyCredits == 0 && cfg.MonthlyCredits == 0 {
+		return nil
+	}
+	u, err := CreditUsage(time.Now())
+	if err != nil {
+		return fmt.Errorf("read credit ledger: %w", err)
+	}
+	if cfg.DailyCredits > 0 && u.Day+cfg.CreditsPerPage > cfg.DailyCredits {
+		return fmt.Errorf("%w: %d/%d daily credits spent", ErrCreditsExhausted, u.Day, cfg.DailyCredits)
+	}
+	if cfg.MonthlyCredits > 0 && u.Month+cfg.CreditsPerPage > cfg.MonthlyCredits {
+		return fmt.Errorf("%w: %d/%d monthly credits spent", ErrCreditsExhausted, u.Month, cfg.MonthlyCredits)
+	}
+	return nil
+}
+
+// spendCredits writes one page to the ledger and warns once per run when
+// usage crosses the warning threshold.
+func spendCredits(query string, page int, warned *bool) {
+	entry := models.CreditEntry{
+		SpentAt:   time.Now(),
+		Workspace: cfg.Workspace,
+		Query:     query,
+		QueryKey:  NormalizeQuery(query),
+		Page:      page,
+		Credits:   cfg.CreditsPerPage,
+	}
+	if err := datastore.Ledger.Create(&entry).Error; err != nil {
+		fmt.Printf("[!] Failed to record credits: %v\n", err)
+	}
+	if *warned || (cfg.DailyCredits == 0 && cfg.MonthlyCredits == 0) {
+		return
+	}
+	u, err := CreditUsage(entry.SpentAt)
+	if err != nil {
+		return
+	}
+	if cfg.DailyCredits > 0 && u.Day*100 >= cfg.DailyCredits*cfg.CreditWarn {
+		fmt.Printf("[!] %d of %d daily credits spent\n", u.Day, cfg.DailyCredits)
+		*warned = true
+	}
+	if cfg.MonthlyCredits > 0 && u.Month*100 >= cfg.MonthlyCredits*cfg.CreditWarn {
+		fmt.Printf("[!] %d of %d monthly credits spent\n", u.Month, cfg.MonthlyCredits)
+		*warned = true
+	}
+}
+
+// cachedRun returns the last complete run of query if it is younger than
+// the cache TTL.
+func cachedRun(query string) (models.QueryCache, bool) {
+	var c models.QueryCache
+	if cfg.CacheTTL <= 0 {
+		return c, false
+	}
+	r := datastore.DB.Where("key = ?", NormalizeQuery(query)).Limit(1).Find(&c)
+	if r.Error != nil || r.RowsAffected == 0 || time.Since(c.FetchedAt) > cfg.CacheTTL {
+		return c, false
+	}
+	return c, true
+}
+
+func cachedStats(c models.QueryCache) Stats {
+	return Stats{Collected: c.Collected, Available: c.Available, CachedAt: &c.FetchedAt}
+}
+
+func storeCache(query string, st Stats) {
+	c := models.QueryCache{
+		Key:       NormalizeQuery(query),
+		Query:     query,
+		FetchedAt: time.Now(),
+		Pages:     st.Pages,
+		Collected: st.Collected,
+		Available: st.Available,
+		Credits:   st.Credits,
+	}
+	if err := datastore.DB.Clauses(clause.OnConflict{UpdateAll: true}).Create(&c).Error; err != nil {
+		fmt.Printf("[!] Failed to cache query: %v\n", err)
+	}
+}
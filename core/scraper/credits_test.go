@@ -0,0 +1,148 @@
+package scraper
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"smuggr.xyz/thughunter/common/models"
+	"smuggr.xyz/thughunter/core/config"
+	"smuggr.xyz/thughunter/core/datastore"
+)
+
+// useWorkspace opens the database of workspace ws in dir and the ledger
+// shared by every workspace there.
+func useWorkspace(t *testing.T, dir, ws string) {
+	t.Helper()
+	datastore.Initialize(filepath.Join(dir, ws+".db"))
+	datastore.InitializeLedger(filepath.Join(dir, "credits.db"), ws)
+	cfg.Workspace = ws
+}
+
+func TestNormalizeQuery(t *testing.T) {
+	tests := map[string]string{
+		"services.port: 5900":                          "services.port: 5900",
+		"  services.port:   5900 \n":                   "services.port: 5900",
+		"a AND b Or c\tNOT d":                          "a and b or c not d",
+		`location.city: "New  York" AND x`:             `location.city: "New  York" and x`,
+		`labels: "AND"   or   labels: "Remote Access"`: `labels: "AND" or labels: "Remote Access"`,
+		"Android": "Android",
+	}
+	for in, want := range tests {
+		if got := NormalizeQuery(in); got != want {
+			t.Errorf("NormalizeQuery(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestBudgetIsSharedByWorkspaces(t *testing.T) {
+	dir := t.TempDir()
+	cfg = config.Scraper{CreditsPerPage: 1, DailyCredits: 3, CreditWarn: 80}
+
+	useWorkspace(t, dir, "acme")
+	var warned bool
+	spendCredits("port: 5900", 1, &warned)
+	spendCredits("port: 5900", 2, &warned)
+	if err := checkBudget(); err != nil {
+		t.Fatalf("third credit refused: %v", err)
+	}
+
+	useWorkspace(t, dir, "beta")
+	spendCredits("port: 5901", 1, &warned)
+	if err := checkBudget(); !errors.Is(err, ErrCreditsExhausted) {
+		t.Fatalf("fourth credit across workspaces: %v", err)
+	}
+	u, err := CreditUsage(time.Now())
+	if err != nil || u.Day != 3 || u.Month != 3 {
+		t.Fatalf("usage %+v, %v", u, err)
+	}
+	ledger, err := Ledger(time.Now().Add(-time.Hour))
+	if err != nil || len(ledger) != 2 {
+		t.Fatalf("ledger %+v, %v", ledger, err)
+	}
+	if ledger[0].Workspace != "acme" || ledger[0].Credits != 2 || ledger[1].Workspace != "beta" {
+		t.Errorf("ledger %+v", ledger)
+	}
+}
+
+func TestBudgetWarning(t *testing.T) {
+	useWorkspace(t, t.TempDir(), "acme")
+	cfg = config.Scraper{CreditsPerPage: 2, DailyCredits: 0, MonthlyCredits: 10, CreditWarn: 50, Workspace: "acme"}
+
+	var warned bool
+	for page := 1; page <= 2; page++ {
+		spendCredits("port: 5900", page, &warned)
+		if warned {
+			t.Fatalf("warned at %d of 10 credits", page*2)
+		}
+	}
+	spendCredits("port: 5900", 3, &warned)
+	if !warned {
+		t.Fatal("no warning at 6 of 10 credits")
+	}
+	spendCredits("port: 5900", 4, &warned)
+	if err := checkBudget(); err != nil {
+		t.Fatalf("page up to 10 of 10 credits refused: %v", err)
+	}
+	spendCredits("port: 5900", 5, &warned)
+	if err := checkBudget(); !errors.Is(err, ErrCreditsExhausted) {
+		t.Fatalf("10 of 10 credits: %v", err)
+	}
+
+	cfg.MonthlyCredits = 0
+	if err := checkBudget(); err != nil {
+		t.Fatalf("no limit: %v", err)
+	}
+}
+
+func TestLedgerTakesOverWorkspaceCredits(t *testing.T) {
+	dir := t.TempDir()
+	datastore.Initialize(filepath.Join(dir, "acme.db"))
+	if err := datastore.DB.AutoMigrate(&models.CreditEntry{}); err != nil {
+		t.Fatal(err)
+	}
+	datastore.DB.Create(&models.CreditEntry{SpentAt: time.Now(), Query: "port: 5900", QueryKey: "port: 5900", Page: 1, Credits: 4})
+
+	datastore.InitializeLedger(filepath.Join(dir, "credits.db"), "acme")
+	if datastore.DB.Migrator().HasTable(&models.CreditEntry{}) {
+		t.Error("workspace database still has its own ledger")
+	}
+	var moved []models.CreditEntry
+	datastore.Ledger.Find(&moved)
+	if len(moved) != 1 || moved[0].Workspace != "acme" || moved[0].Credits != 4 {
+		t.Errorf("ledger holds %+v", moved)
+	}
+}
+
+func TestQueryCache(t *testing.T) {
+	dir := t.TempDir()
+	useWorkspace(t, dir, "acme")
+	cfg = config.Scraper{CacheTTL: time.Hour, Workspace: "acme"}
+
+	storeCache("services.port: 5900 AND labels: vnc", Stats{Pages: 2, Collected: 40, Available: 90, Credits: 2})
+	c, ok := cachedRun("  services.port:  5900 and labels: vnc")
+	if !ok || c.Collected != 40 || c.Available != 90 {
+		t.Fatalf("normalized repeat not cached: %+v %v", c, ok)
+	}
+	if _, ok := cachedRun(`services.port: 5900 AND labels: "vnc"`); ok {
+		t.Error("different query answered from the cache")
+	}
+
+	cfg.CacheTTL = 0
+	if _, ok := cachedRun("services.port: 5900 AND labels: vnc"); ok {
+		t.Error("cache used with a zero TTL")
+	}
+	cfg.CacheTTL = time.Hour
+	datastore.DB.Model(&models.QueryCache{}).Where("1 = 1").Update("fetched_at", time.Now().Add(-2*time.Hour))
+	if _, ok := cachedRun("services.port: 5900 AND labels: vnc"); ok {
+		t.Error("expired run answered from the cache")
+	}
+
+	useWorkspace(t, dir, "beta")
+	storeCache("port: 22", Stats{Collected: 1})
+	useWorkspace(t, dir, "acme")
+	if _, ok := cachedRun("port: 22"); ok {
+		t.Error("another workspace's run answered from the cache")
+	}
+}
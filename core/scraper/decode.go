@@ -0,0 +1,225 @@
+// core/scraper/decode.go
+package scraper
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+
+	"smuggr.xyz/thughunter/common/models"
+)
+
+// DecoderVersion is stored with every API record; bump it when decoding
+// changes so old records can be found and reparsed.
+const DecoderVersion = "api/v1"
+
+var serviceNameKeys = []string{"extended_service_name", "service_name", "protocol", "name"}
+
+// DecodeHosts finds host records anywhere in a Censys JSON response. Any
+// object with a valid "ip" and a non-empty "services" list counts, which
+// keeps decoding working when the response envelope changes.
+func DecodeHosts(body []byte) ([]models.Host, error) {
+	recs, err := decodeRecords(body)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string]*models.Host)
+	var order []string
+	for _, r := range recs {
+		if existing, ok := found[r.Host.IP]; ok {
+			mergeHost(existing, r.Host)
+			continue
+		}
+		h := r.Host
+		found[h.IP] = &h
+		order = append(order, h.IP)
+	}
+	hosts := make([]models.Host, 0, len(order))
+	for _, ip := range order {
+		hosts = append(hosts, *found[ip])
+	}
+	return hosts, nil
+}
+
+func decodeRecords(body []byte) ([]parsedRecord, error) {
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	var recs []parsedRecord
+	walk(v, func(h models.Host, obj map[string]interface{}) {
+		raw, _ := json.Marshal(obj)
+		recs = append(recs, parsedRecord{Source: SourceAPI, Version: DecoderVersion, Host: h, Raw: raw})
+	})
+	return recs, nil
+}
+
+// decodeCaptured keeps the raw responses and decodes the host records in
+// them.
+func decodeCaptured(responses []capturedResponse, page int) []parsedRecord {
+	if err := saveResponses(cfg.CaptureDir, page, responses); err != nil {
+		fmt.Printf("[!] Failed to keep captured responses: %v\n", err)
+	}
+	var recs []parsedRecord
+	for _, r := range responses {
+		decoded, err := decodeRecords(r.Body)
+		if err != nil {
+			fmt.Printf("[!] %s: %v\n", r.URL, err)
+			continue
+		}
+		recs = append(recs, decoded...)
+	}
+	return recs
+}
+
+func walk(v interface{}, emit func(models.Host, map[string]interface{})) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if h, ok := hostFromObject(t); ok {
+			emit(h, t)
+			return
+		}
+		for _, child := range t {
+			walk(child, emit)
+		}
+	case []interface{}:
+		for _, child := range t {
+			walk(child, emit)
+		}
+	}
+}
+
+func hostFromObject(m map[string]interface{}) (models.Host, bool) {
+	ip, _ := m["ip"].(string)
+	services, _ := m["services"].([]interface{})
+	if net.ParseIP(ip) == nil || len(services) == 0 {
+		return models.Host{}, false
+	}
+
+	h := models.Host{IP: ip, Services: make(models.JSONServiceMap)}
+	for _, s := range services {
+		svc, ok := s.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		port, ok := svc["port"].(float64)
+		if !ok {
+			continue
+		}
+		name := "UNKNOWN"
+		for _, key := range serviceNameKeys {
+			if v, ok := svc[key].(string); ok && v != "" {
+				name = strings.ToUpper(v)
+				break
+			}
+		}
+		addService(h.Services, name, int(port))
+	}
+	if len(h.Services) == 0 {
+		return models.Host{}, false
+	}
+
+	h.Hostname = firstHostname(m)
+	h.Location = location(m["location"])
+	h.Labels = labels(m["labels"])
+	return h, true
+}
+
+// addService keeps the first port of a service under its plain name, as the
+// scanner expects, and further ports as "NAME/port".
+func addService(services models.JSONServiceMap, name string, port int) {
+	existing, ok := services[name]
+	if !ok {
+		services[name] = port
+		return
+	}
+	if existing != port {
+		services[fmt.Sprintf("%s/%d", name, port)] = port
+	}
+}
+
+func firstHostname(m map[string]interface{}) string {
+	if dns, ok := m["dns"].(map[string]interface{}); ok {
+		if names, ok := dns["names"].([]interface{}); ok && len(names) > 0 {
+			if s, ok := names[0].(string); ok {
+				return s
+			}
+		}
+		if rev, ok := dns["reverse_dns"].(map[string]interface{}); ok {
+			if names, ok := rev["names"].([]interface{}); ok && len(names) > 0 {
+				if s, ok := names[0].(string); ok {
+					return s
+				}
+			}
+		}
+	}
+	if s, ok := m["name"].(string); ok {
+		return s
+	}
+	return ""
+}
+
+func location(v interface{}) string {
+	loc, ok := v.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	var parts []string
+	for _, key := range []string{"city", "province", "country"} {
+		if s, ok := loc[key].(string); ok && s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func labels(v interface{}) models.JSONStringSlice {
+	list, ok := v.([]interface{})
+	if !ok {
+		return nil
+	}
+	var out models.JSONStringSlice
+	for _, l := range list {
+		switch t := l.(type) {
+		case string:
+			out = append(out, t)
+		case map[string]interface{}:
+			if s, ok := t["value"].(string); ok {
+				out = append(out, s)
+			}
+		}
+	}
+	return out
+}
+
+// mergeHost adds what b knows to a. Services are unioned as addService
+// does; text fields are only filled when a has none.
+func mergeHost(a *models.Host, b models.Host) {
+	if a.Services == nil {
+		a.Services = make(models.JSONServiceMap)
+	}
+	for name, port := range b.Services {
+		addService(a.Services, name, port)
+	}
+	if a.Hostname == "" {
+		a.Hostname = b.Hostname
+	}
+	if a.Location == "" {
+		a.Location = b.Location
+	}
+	for _, l := range b.Labels {
+		if !containsString(a.Labels, l) {
+			a.Labels = append(a.Labels, l)
+		}
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package scraper
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"smuggr.xyz/thughunter/common/models"
+)
+
+func TestDecodeHosts(t *testing.T) {
+	body, err := os.ReadFile(filepath.Join("testdata", "search_response.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := DecodeHosts(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []models.Host{
+		{
+			IP:       "198.51.100.7",
+			Hostname: "desk.example.net",
+			Location: "Munich, Bavaria, Germany",
+			Labels:   models.JSONStringSlice{"remote-access", "vnc-no-auth"},
+			Services: models.JSONServiceMap{"VNC": 5900, "VNC/5901": 5901, "SSH": 22, "UNKNOWN": 8443},
+		},
+		{
+			IP:       "203.0.113.9",
+			Hostname: "ptr.example.org",
+			Location: "France",
+			Labels:   models.JSONStringSlice{"login-page"},
+			Services: models.JSONServiceMap{"HTTP": 80, "HTTPS": 443, "HTTP/8080": 8080},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %+v\nwant %+v", got, want)
+	}
+
+	recs, err := decodeRecords(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 3 {
+		t.Fatalf("%d records, want one per host object", len(recs))
+	}
+	for _, r := range recs {
+		if r.Source != SourceAPI || r.Version != DecoderVersion || len(r.Raw) == 0 {
+			t.Errorf("record %s: source %q, version %q, %d raw bytes", r.Host.IP, r.Source, r.Version, len(r.Raw))
+		}
+		// The archived object must decode to the same host on its own.
+		again, err := decodeRecords(r.Raw)
+		if err != nil || len(again) != 1 || !reflect.DeepEqual(again[0].Host, r.Host) {
+			t.Errorf("record %s does not reparse: %v %+v", r.Host.IP, err, again)
+		}
+	}
+
+	if _, err := DecodeHosts([]byte(`{"result": `)); err == nil {
+		t.Error("truncated response decoded without an error")
+	}
+	if hosts, err := DecodeHosts([]byte(`{"result": {"hits": []}}`)); err != nil || len(hosts) != 0 {
+		t.Errorf("empty response: %v %v", hosts, err)
+	}
+}
@@ -0,0 +1,91 @@
+// core/scraper/history.go
+package scraper
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"smuggr.xyz/thughunter/common/models"
+	"smuggr.xyz/thughunter/core/datastore"
+)
+
+// CauseReparse marks host changes made by Reparse rather than a query.
+const CauseReparse = "reparse"
+
+// hostFields flattens the fields of h that are tracked in the change
+// history. Every service is its own "services.NAME" field.
+func hostFields(h models.Host) map[string]string {
+	f := map[string]string{
+		"hostname": h.Hostname,
+		"location": h.Location,
+		"labels":   strings.Join(h.Labels, ", "),
+		"country":  h.Country,
+		"city":     h.City,
+		"org":      h.Org,
+	}
+	if h.ASN != 0 {
+		f["asn"] = "AS" + strconv.FormatUint(uint64(h.ASN), 10)
+	}
+	for name, port := range h.Services {
+		f["services."+name] = strconv.Itoa(port)
+	}
+	return f
+}
+
+// hostChanges lists the fields that differ between before and after,
+// sorted by field name.
+func hostChanges(before, after models.Host, at time.Time, cause string) []models.HostChange {
+	old, cur := hostFields(before), hostFields(after)
+	keys := make([]string, 0, len(cur))
+	for k := range cur {
+		keys = append(keys, k)
+	}
+	for k := range old {
+		if _, ok := cur[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var changes []models.HostChange
+	for _, k := range keys {
+		if old[k] == cur[k] {
+			continue
+		}
+		changes = append(changes, models.HostChange{
+			IP:        after.IP,
+			ChangedAt: at,
+			Field:     k,
+			Old:       old[k],
+			New:       cur[k],
+			SourceID:  after.Sources[k],
+			Cause:     cause,
+		})
+	}
+	return changes
+}
+
+// cloneHost copies h deeply enough that applying records to the copy
+// leaves h untouched.
+func cloneHost(h models.Host) models.Host {
+	c := h
+	c.Labels = append(models.JSONStringSlice(nil), h.Labels...)
+	c.Services = make(models.JSONServiceMap, len(h.Services))
+	for k, v := range h.Services {
+		c.Services[k] = v
+	}
+	c.Sources = make(models.JSONSourceMap, len(h.Sources))
+	for k, v := range h.Sources {
+		c.Sources[k] = v
+	}
+	return c
+}
+
+// History returns the recorded changes of a host, oldest first.
+func History(ip string) ([]models.HostChange, error) {
+	var changes []models.HostChange
+	err := datastore.DB.Where("ip = ?", ip).Order("changed_at, id").Find(&changes).Error
+	return changes, err
+}
@@ -0,0 +1,109 @@
+// core/scraper/pages.go
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/chromedp/chromedp"
+)
+
+const (
+	StopMaxPages     = "max pages"
+	StopCreditBudget = "credit budget"
+	StopCreditLimit  = "daily or monthly credits"
+	StopPagination   = "pagination failed"
+
+	pageLoadTimeout = 30 * time.Second
+	loginTimeout    = 10 * time.Second
+)
+
+var availablePattern = regexp.MustCompile(`(?i)([\d,.]+)\s+(?:results|hosts)\b`)
+
+type Stats struct {
+	New       int
+	Updated   int
+	Pages     int
+	Collected int
+	Available int
+	Credits   int
+	FromAPI   int
+	StoppedBy string
+	// CachedAt is set when the query was answered from the cache instead
+	// of Censys.
+	CachedAt *time.Time
+}
+
+func (s Stats) progress() string {
+	if s.Available > 0 {
+		return fmt.Sprintf("%d/%d", s.Collected, s.Available)
+	}
+	return strconv.Itoa(s.Collected)
+}
+
+// parseAvailable reads the total hit count Censys shows above the results,
+// or 0 when the page does not show one.
+func parseAvailable(doc *goquery.Document) int {
+	m := availablePattern.FindStringSubmatch(doc.Find("main").Text())
+	if m == nil {
+		m = availablePattern.FindStringSubmatch(doc.Text())
+	}
+	if m == nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(strings.NewReplacer(",", "", ".", "").Replace(m[1]))
+	return n
+}
+
+// waitResults waits until the first result card has rendered, or the page
+// says there are no results. A page that shows neither has changed its
+// markup and returns ErrSelectorsOutdated.
+func waitResults(ctx context.Context) error {
+	js := `document.querySelector(` + strconv.Quote(selectors.Card) + `) !== null || /` + noResultsText + `/i.test(document.body ? document.body.innerText : "")`
+	err := chromedp.Run(ctx, chromedp.Poll(js, nil, chromedp.WithPollingTimeout(pageLoadTimeout)))
+	if err != nil && ctx.Err() == nil {
+		return fmt.Errorf("neither a result card nor %q within %s: %w", noResultsText, pageLoadTimeout, ErrSelectorsOutdated)
+	}
+	return err
+}
+
+func hasNextPage(ctx context.Context) bool {
+	var ok bool
+	js := `(() => {
+		const b = document.querySelector(` + strconv.Quote(selectors.NextPage) + `);
+		return !!b && !b.disabled && b.getAttribute("aria-disabled") !== "true";
+	})()`
+	if err := chromedp.Run(ctx, chromedp.Evaluate(js, &ok)); err != nil {
+		return false
+	}
+	return ok
+}
+
+// nextPage clicks the pager and waits until the first result differs from
+// the one on the current page.
+func nextPage(ctx context.Context, current *goquery.Document) error {
+	first := firstResult(current)
+	if err := withRetry(ctx, "click next page", func() error {
+		return chromedp.Run(ctx, chromedp.Click(selectors.NextPage, chromedp.ByQuery, chromedp.NodeVisible))
+	}); err != nil {
+		return err
+	}
+
+	js := `(() => { const a = document.querySelector(` + strconv.Quote(selectors.Card+" "+selectors.Link) + `); return !!a && a.getAttribute("href") !== ` + strconv.Quote(first) + `; })()`
+	err := chromedp.Run(ctx, chromedp.Poll(js, nil, chromedp.WithPollingTimeout(pageLoadTimeout)))
+	if errors.Is(err, chromedp.ErrPollingTimeout) {
+		return errors.New("next page did not load in time")
+	}
+	return err
+}
+
+func firstResult(doc *goquery.Document) string {
+	href, _ := doc.Find(selectors.Card + " " + selectors.Link).First().Attr("href")
+	return href
+}
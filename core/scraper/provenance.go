@@ -0,0 +1,354 @@
+// core/scraper/provenance.go
+package scraper
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"gorm.io/gorm"
+
+	"smuggr.xyz/thughunter/common/models"
+	"smuggr.xyz/thughunter/core/datastore"
+	"smuggr.xyz/thughunter/core/enrich"
+)
+
+const (
+	SourceHTML = "censys_html"
+	SourceAPI  = "censys_api"
+)
+
+// parsedRecord is one host as a parser saw it, together with the raw
+// payload it was parsed from.
+type parsedRecord struct {
+	Source  string
+	Version string
+	Host    models.Host
+	Raw     []byte
+}
+
+// ingest archives the records of one result page and applies them to the
+// hosts they describe, reporting how many hosts were created and updated.
+func ingest(query, batch string, page int, recs []parsedRecord) (created, updated int) {
+	now := time.Now()
+	byIP := make(map[string][]parsedRecord)
+	var order []string
+	for _, r := range recs {
+		if _, ok := byIP[r.Host.IP]; !ok {
+			order = append(order, r.Host.IP)
+		}
+		byIP[r.Host.IP] = append(byIP[r.Host.IP], r)
+	}
+	// DOM records go first so the API, which is more complete, wins.
+	for _, ip := range order {
+		group := byIP[ip]
+		sortBySource(group)
+		isNew, err := ingestHost(query, batch, page, now, group)
+		if err != nil {
+			fmt.Printf("[!] Failed to save %s: %v\n", ip, err)
+		} else if isNew {
+			created++
+		} else {
+			updated++
+		}
+	}
+	return created, updated
+}
+
+func ingestHost(query, batch string, page int, now time.Time, recs []parsedRecord) (bool, error) {
+	ids := make([]uint, len(recs))
+	for i, r := range recs {
+		payload, err := compress(r.Raw)
+		if err != nil {
+			return false, err
+		}
+		sr := models.SourceRecord{
+			IP:            r.Host.IP,
+			Source:        r.Source,
+			Query:         query,
+			Batch:         batch,
+			Page:          page,
+			ParserVersion: r.Version,
+			Payload:       payload,
+			CapturedAt:    now,
+		}
+		if err := datastore.DB.Create(&sr).Error; err != nil {
+			return false, fmt.Errorf("archive %s record: %w", r.Source, err)
+		}
+		ids[i] = sr.ID
+	}
+
+	var h models.Host
+	res := datastore.DB.Where("ip = ?", recs[0].Host.IP).Limit(1).Find(&h)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	isNew := res.RowsAffected == 0
+	if isNew {
+		h = models.Host{IP: recs[0].Host.IP, FirstSeen: now}
+	}
+	before := cloneHost(h)
+	applyRecords(&h, recs, ids)
+	h.LastSeen = now
+	if enrich.Available() {
+		if err := enrich.Host(&h); err != nil {
+			fmt.Printf("[!] GeoIP enrichment failed: %v\n", err)
+		}
+	}
+
+	changes := hostChanges(before, h, now, query)
+	err := datastore.DB.Transaction(func(tx *gorm.DB) error {
+		if isNew {
+			if err := tx.Create(&h).Error; err != nil {
+				return err
+			}
+		} else if err := tx.Save(&h).Error; err != nil {
+			return err
+		}
+		if len(changes) > 0 {
+			return tx.Create(&changes).Error
+		}
+		return nil
+	})
+	return isNew, err
+}
+
+// applyRecords merges the records of one batch into h and points each
+// field it touches at the record it came from. Services and labels are
+// unioned with what earlier queries found; hostname and location take the
+// newer value. Fields a record is silent on are kept.
+func applyRecords(h *models.Host, recs []parsedRecord, ids []uint) {
+	if h.Sources == nil {
+		h.Sources = make(models.JSONSourceMap)
+	}
+	if h.Services == nil {
+		h.Services = make(models.JSONServiceMap)
+	}
+	for i, r := range recs {
+		id := ids[i]
+		if r.Host.Hostname != "" {
+			h.Hostname = r.Host.Hostname
+			h.Sources["hostname"] = id
+		}
+		if r.Host.Location != "" {
+			h.Location = r.Host.Location
+			h.Sources["location"] = id
+		}
+		for _, l := range r.Host.Labels {
+			if !containsString(h.Labels, l) {
+				h.Labels = append(h.Labels, l)
+				h.Sources["labels"] = id
+			}
+		}
+		for name, port := range r.Host.Services {
+			h.Services[name] = port
+			h.Sources["services."+name] = id
+		}
+	}
+}
+
+func sortBySource(recs []parsedRecord) {
+	var out []parsedRecord
+	for _, src := range []string{SourceHTML, SourceAPI} {
+		for _, r := range recs {
+			if r.Source == src {
+				out = append(out, r)
+			}
+		}
+	}
+	copy(recs, out)
+}
+
+// reparseRecord runs the current parser for its source over a stored
+// payload.
+func reparseRecord(sr models.SourceRecord) (parsedRecord, error) {
+	raw, err := decompress(sr.Payload)
+	if err != nil {
+		return parsedRecord{}, err
+	}
+	var recs []parsedRecord
+	switch sr.Source {
+	case SourceHTML:
+		doc, err := goquery.NewDocumentFromReader(bytes.NewReader(raw))
+		if err != nil {
+			return parsedRecord{}, err
+		}
+		recs, err = selectors.parseCards(doc)
+		if err != nil {
+			return parsedRecord{}, err
+		}
+	case SourceAPI:
+		recs, err = decodeRecords(raw)
+		if err != nil {
+			return parsedRecord{}, err
+		}
+	default:
+		return parsedRecord{}, fmt.Errorf("unknown source %q", sr.Source)
+	}
+	for _, r := range recs {
+		if r.Host.IP == sr.IP {
+			return r, nil
+		}
+	}
+	return parsedRecord{}, fmt.Errorf("record no longer parses to host %s", sr.IP)
+}
+
+// ReparseStats summarises a Reparse run.
+type ReparseStats struct {
+	Records int
+	Hosts   int
+	Changed int
+	Failed  int
+}
+
+// Reparse rebuilds hosts from their archived source records with the
+// current parsers, so a parser fix can be applied without querying Censys
+// again. An empty ip rebuilds every archived host; with dryRun nothing is
+// written. Hosts with a failing record are skipped.
+func Reparse(ip string, dryRun bool) (ReparseStats, error) {
+	var st ReparseStats
+	var ips []string
+	tx := datastore.DB.Model(&models.SourceRecord{}).Distinct("ip").Order("ip")
+	if ip != "" {
+		tx = tx.Where("ip = ?", ip)
+	}
+	if err := tx.Pluck("ip", &ips).Error; err != nil {
+		return st, err
+	}
+
+	for _, addr := range ips {
+		var records []models.SourceRecord
+		if err := datastore.DB.Where("ip = ?", addr).Order("captured_at, id").Find(&records).Error; err != nil {
+			return st, err
+		}
+		var h models.Host
+		res := datastore.DB.Where("ip = ?", addr).Limit(1).Find(&h)
+		if res.Error != nil {
+			return st, res.Error
+		}
+		if res.RowsAffected == 0 {
+			h = models.Host{IP: addr, FirstSeen: records[0].CapturedAt, LastSeen: records[len(records)-1].CapturedAt}
+		}
+		before := cloneHost(h)
+		var batches [][]parsedRecord
+		var batchIDs [][]uint
+		failed := 0
+		for start := 0; start < len(records); {
+			end := start
+			for end < len(records) && records[end].Batch == records[start].Batch {
+				end++
+			}
+			var recs []parsedRecord
+			var ids []uint
+			for _, sr := range records[start:end] {
+				st.Records++
+				r, err := reparseRecord(sr)
+				if err != nil {
+					fmt.Printf("[!] Record %d (%s, %s): %v\n", sr.ID, sr.IP, sr.Source, err)
+					failed++
+					continue
+				}
+				recs = append(recs, r)
+				ids = append(ids, sr.ID)
+			}
+			batches, batchIDs = append(batches, recs), append(batchIDs, ids)
+			start = end
+		}
+		st.Failed += failed
+		if failed > 0 {
+			fmt.Printf("[!] Skipping %s, %d of its records failed to parse\n", addr, failed)
+			continue
+		}
+		resetSourced(&h)
+		for i, recs := range batches {
+			applyRecords(&h, recs, batchIDs[i])
+		}
+		if res.RowsAffected == 0 && enrich.Available() {
+			if err := enrich.Host(&h); err != nil {
+				fmt.Printf("[!] GeoIP enrichment failed: %v\n", err)
+			}
+		}
+
+		st.Hosts++
+		changes := hostChanges(before, h, time.Now(), CauseReparse)
+		if len(changes) == 0 && fmt.Sprint(h.Sources) == fmt.Sprint(before.Sources) {
+			continue
+		}
+		st.Changed++
+		if dryRun {
+			fmt.Printf("%s would change\n", addr)
+			for _, c := range changes {
+				fmt.Printf("  %s: %q -> %q\n", c.Field, c.Old, c.New)
+			}
+			continue
+		}
+		err := datastore.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Save(&h).Error; err != nil {
+				return err
+			}
+			if len(changes) > 0 {
+				return tx.Create(&changes).Error
+			}
+			return nil
+		})
+		if err != nil {
+			return st, fmt.Errorf("save %s: %w", addr, err)
+		}
+	}
+	return st, nil
+}
+
+// resetSourced clears the fields that have a source record.
+func resetSourced(h *models.Host) {
+	sources := make(models.JSONSourceMap)
+	for field, id := range h.Sources {
+		switch {
+		case field == "hostname":
+			h.Hostname = ""
+		case field == "location":
+			h.Location = ""
+		case field == "labels":
+			h.Labels = nil
+		case strings.HasPrefix(field, "services."):
+			delete(h.Services, strings.TrimPrefix(field, "services."))
+		default:
+			sources[field] = id
+		}
+	}
+	h.Sources = sources
+}
+
+func compress(raw []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(raw); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decompress(payload []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
+
+// RawRecord returns a stored record with its payload decompressed.
+func RawRecord(id uint) (models.SourceRecord, []byte, error) {
+	var sr models.SourceRecord
+	if err := datastore.DB.First(&sr, id).Error; err != nil {
+		return sr, nil, err
+	}
+	raw, err := decompress(sr.Payload)
+	return sr, raw, err
+}
@@ -0,0 +1,213 @@
+package scraper
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"smuggr.xyz/thughunter/common/models"
+	"smuggr.xyz/thughunter/core/datastore"
+)
+
+const mergeIP = "198.51.100.7"
+
+// fixtureRecords returns the records the page and API fixtures hold for
+// ip, parsed as a scrape would.
+func fixtureRecords(t *testing.T, ip string) (dom, api parsedRecord) {
+	t.Helper()
+	html, err := os.ReadFile(filepath.Join("testdata", "results.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(string(html)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	domRecs, err := selectors.parseCards(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := os.ReadFile(filepath.Join("testdata", "search_response.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	apiRecs, err := decodeRecords(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range domRecs {
+		if r.Host.IP == ip {
+			dom = r
+		}
+	}
+	for _, r := range apiRecs {
+		if r.Host.IP == ip {
+			api = r
+		}
+	}
+	if dom.Host.IP == "" || api.Host.IP == "" {
+		t.Fatalf("fixtures lack %s", ip)
+	}
+	return dom, api
+}
+
+func loadHost(t *testing.T, ip string) models.Host {
+	t.Helper()
+	var h models.Host
+	if err := datastore.DB.Where("ip = ?", ip).First(&h).Error; err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+func changeMap(t *testing.T, ip, cause string) map[string][2]string {
+	t.Helper()
+	changes, err := History(ip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := make(map[string][2]string)
+	for _, c := range changes {
+		if c.Cause == cause {
+			out[c.Field] = [2]string{c.Old, c.New}
+		}
+	}
+	return out
+}
+
+func recordID(t *testing.T, ip, source, batch string) uint {
+	t.Helper()
+	var sr models.SourceRecord
+	if err := datastore.DB.Where("ip = ? AND source = ? AND batch = ?", ip, source, batch).First(&sr).Error; err != nil {
+		t.Fatal(err)
+	}
+	return sr.ID
+}
+
+func TestIngestMergesDOMAndAPI(t *testing.T) {
+	datastore.Initialize(filepath.Join(t.TempDir(), "merge.db"))
+	dom, api := fixtureRecords(t, mergeIP)
+
+	// The API record comes first here; ingest still applies it last.
+	created, updated := ingest("q1", "b1", 1, []parsedRecord{api, dom})
+	if created != 1 || updated != 0 {
+		t.Fatalf("created %d, updated %d", created, updated)
+	}
+	h := loadHost(t, mergeIP)
+	domID, apiID := recordID(t, mergeIP, SourceHTML, "b1"), recordID(t, mergeIP, SourceAPI, "b1")
+
+	if h.Hostname != "desk.example.net" || h.Location != "Munich, Bavaria, Germany" {
+		t.Errorf("API did not win: hostname %q, location %q", h.Hostname, h.Location)
+	}
+	wantServices := models.JSONServiceMap{"VNC": 5900, "HTTP": 80, "VNC/5901": 5901, "SSH": 22, "UNKNOWN": 8443}
+	if !reflect.DeepEqual(h.Services, wantServices) {
+		t.Errorf("services %v, want %v", h.Services, wantServices)
+	}
+	if want := (models.JSONStringSlice{"remote-access", "login-page", "vnc-no-auth"}); !reflect.DeepEqual(h.Labels, want) {
+		t.Errorf("labels %v, want %v", h.Labels, want)
+	}
+	for field, id := range map[string]uint{
+		"hostname":      apiID,
+		"location":      apiID,
+		"labels":        apiID,
+		"services.VNC":  apiID,
+		"services.HTTP": domID,
+		"services.SSH":  apiID,
+	} {
+		if h.Sources[field] != id {
+			t.Errorf("%s from record %d, want %d", field, h.Sources[field], id)
+		}
+	}
+
+	got := changeMap(t, mergeIP, "q1")
+	want := map[string][2]string{
+		"hostname":          {"", "desk.example.net"},
+		"location":          {"", "Munich, Bavaria, Germany"},
+		"labels":            {"", "remote-access, login-page, vnc-no-auth"},
+		"services.VNC":      {"", "5900"},
+		"services.VNC/5901": {"", "5901"},
+		"services.HTTP":     {"", "80"},
+		"services.SSH":      {"", "22"},
+		"services.UNKNOWN":  {"", "8443"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("changes %v, want %v", got, want)
+	}
+
+	// A later page only: newer text wins, services and labels are kept,
+	// and only what changed is recorded.
+	created, updated = ingest("q2", "b2", 1, []parsedRecord{dom})
+	if created != 0 || updated != 1 {
+		t.Fatalf("created %d, updated %d", created, updated)
+	}
+	h = loadHost(t, mergeIP)
+	if h.Hostname != "vnc.example.net" || h.Location != "Berlin, Germany" || len(h.Services) != 5 || len(h.Labels) != 3 {
+		t.Errorf("after the page: %q %q %v %v", h.Hostname, h.Location, h.Services, h.Labels)
+	}
+	got = changeMap(t, mergeIP, "q2")
+	want = map[string][2]string{
+		"hostname": {"desk.example.net", "vnc.example.net"},
+		"location": {"Munich, Bavaria, Germany", "Berlin, Germany"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("changes %v, want %v", got, want)
+	}
+
+	created, updated = ingest("q2", "b3", 1, []parsedRecord{dom})
+	if created != 0 || updated != 1 {
+		t.Fatalf("created %d, updated %d", created, updated)
+	}
+	if changes, _ := History(mergeIP); len(changes) != 10 {
+		t.Errorf("unchanged page recorded changes: %d rows", len(changes))
+	}
+}
+
+func TestReparseRestoresArchivedValues(t *testing.T) {
+	datastore.Initialize(filepath.Join(t.TempDir(), "reparse.db"))
+	dom, api := fixtureRecords(t, mergeIP)
+	ingest("q1", "b1", 1, []parsedRecord{dom, api})
+	want := loadHost(t, mergeIP)
+
+	// Damage what a parser bug would have: a wrong hostname and a lost
+	// service. Fields without a source record are left alone.
+	datastore.DB.Model(&models.Host{}).Where("ip = ?", mergeIP).Updates(map[string]interface{}{
+		"hostname": "wrong.example",
+		"services": models.JSONServiceMap{"VNC": 5900},
+		"org":      "Example Org",
+	})
+
+	st, err := Reparse("", true)
+	if err != nil || st.Hosts != 1 || st.Changed != 1 || st.Records != 2 || st.Failed != 0 {
+		t.Fatalf("dry run %+v, %v", st, err)
+	}
+	if h := loadHost(t, mergeIP); h.Hostname != "wrong.example" {
+		t.Fatal("dry run wrote the host")
+	}
+
+	if _, err := Reparse(mergeIP, false); err != nil {
+		t.Fatal(err)
+	}
+	h := loadHost(t, mergeIP)
+	if h.Hostname != want.Hostname || !reflect.DeepEqual(h.Services, want.Services) || h.Org != "Example Org" {
+		t.Errorf("reparsed to %q %v %q, want %q %v", h.Hostname, h.Services, h.Org, want.Hostname, want.Services)
+	}
+	if !reflect.DeepEqual(h.Sources, want.Sources) {
+		t.Errorf("sources %v, want %v", h.Sources, want.Sources)
+	}
+	got := changeMap(t, mergeIP, CauseReparse)
+	if got["hostname"] != [2]string{"wrong.example", "desk.example.net"} || got["services.SSH"] != [2]string{"", "22"} {
+		t.Errorf("reparse changes %v", got)
+	}
+	if _, ok := got["org"]; ok {
+		t.Error("reparse touched a field without a source record")
+	}
+
+	st, err = Reparse("", false)
+	if err != nil || st.Changed != 0 {
+		t.Errorf("second reparse %+v, %v", st, err)
+	}
+}
@@ -0,0 +1,31 @@
+// core/scraper/retry.go
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// withRetry runs fn until it succeeds or cfg.Retries attempts are used,
+// doubling cfg.RetryBackoff between attempts.
+func withRetry(ctx context.Context, what string, fn func() error) error {
+	backoff := cfg.RetryBackoff
+	var err error
+	for attempt := 1; attempt <= cfg.Retries; attempt++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+		if ctx.Err() != nil || attempt == cfg.Retries {
+			break
+		}
+		fmt.Printf("[!] %s failed (attempt %d/%d): %v, retrying in %s\n", what, attempt, cfg.Retries, err, backoff)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return fmt.Errorf("%s: %w", what, ctx.Err())
+		}
+		backoff *= 2
+	}
+	return fmt.Errorf("%s: %w", what, err)
+}
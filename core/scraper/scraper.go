@@ -4,11 +4,10 @@ package scraper
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
-	"log"
 	"net/url"
 	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -16,37 +15,66 @@ import (
 	"github.com/PuerkitoBio/goquery"
 	"github.com/chromedp/cdproto/network"
 	"github.com/chromedp/chromedp"
-	"gorm.io/gorm"
 
-	"smuggr.xyz/thughunter/common/models"
-	"smuggr.xyz/thughunter/core/datastore"
+	"smuggr.xyz/thughunter/core/audit"
+	"smuggr.xyz/thughunter/core/config"
 )
 
-func LaunchUpdater(query string) (newCount, updCount int) {
-	u := "https://platform.censys.io/search?q=" + url.QueryEscape(query)
-	userDataDir := os.Getenv("CDP_USER_DATA_DIR")
-	if userDataDir == "" {
-		userDataDir = "./cdp-profile"
+var cfg config.Scraper
+
+var ErrNotLoggedIn = errors.New("not logged in to Censys")
+
+const searchURL = "https://platform.censys.io/search"
+
+func Configure(c config.Scraper) error {
+	sel, err := LoadSelectors(c.SelectorsPath)
+	if err != nil {
+		return fmt.Errorf("load selectors: %w", err)
 	}
+	cfg, selectors = c, sel
+	return nil
+}
 
-	opts := append(chromedp.DefaultExecAllocatorOptions[:],
-		chromedp.UserDataDir(userDataDir),
-		chromedp.Flag("headless", false),
-		chromedp.Flag("disable-blink-features", "AutomationControlled"),
-		chromedp.Flag("enable-automation", false),
-		chromedp.Flag("disable-infobars", true),
-		chromedp.Flag("start-maximized", true),
-		chromedp.Flag("no-default-browser-check", true),
-		chromedp.Flag("no-first-run", true),
-	)
+// LaunchUpdater runs a single query in its own browser session. See
+// Session.Run.
+func LaunchUpdater(query string, refresh bool) (Stats, error) {
+	if c, ok := cachedRun(query); ok && !refresh {
+		return cachedStats(c), nil
+	}
+	s, err := NewSession()
+	if err != nil {
+		return Stats{}, err
+	}
+	defer s.Close()
+	return s.Run(query, refresh)
+}
 
-	allocCtx, cancel := chromedp.NewExecAllocator(context.Background(), opts...)
-	defer cancel()
+// Session is one browser shared by the queries of a batch, so Chrome is
+// started and the login checked once instead of per query.
+type Session struct {
+	ctx     context.Context
+	cancels []context.CancelFunc
+	capt    *capture
+}
 
-	ctx, cancelCtx := chromedp.NewContext(allocCtx)
-	defer cancelCtx()
+// NewSession starts the browser and checks the login.
+func NewSession() (*Session, error) {
+	s := &Session{}
+	if err := s.start(searchURL); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Close shuts the browser down.
+func (s *Session) Close() {
+	for i := len(s.cancels) - 1; i >= 0; i-- {
+		s.cancels[i]()
+	}
+	s.ctx, s.cancels, s.capt = nil, nil, nil
+}
 
-	stealthScript := `
+const stealthScript = `
 	Object.defineProperty(navigator, 'webdriver', { get: () => undefined });
 	window.chrome = { runtime: {} };
 	Object.defineProperty(navigator, 'languages', { get: () => ['en-US', 'en'] });
@@ -62,83 +90,198 @@ func LaunchUpdater(query string) (newCount, updCount int) {
 	Object.defineProperty(navigator, 'userAgent', { get: () => newUA });
 	`
 
-	if err := chromedp.Run(ctx,
-		chromedp.Evaluate(stealthScript, nil),
-	); err != nil {
-		log.Fatalf("failed to inject stealth JS: %v", err)
-	}
+// start launches the browser, opens u and makes sure the user is logged in
+// before returning.
+func (s *Session) start(u string) error {
+	opts := append(chromedp.DefaultExecAllocatorOptions[:],
+		chromedp.UserDataDir(cfg.UserDataDir),
+		chromedp.Flag("headless", false),
+		chromedp.Flag("disable-blink-features", "AutomationControlled"),
+		chromedp.Flag("enable-automation", false),
+		chromedp.Flag("disable-infobars", true),
+		chromedp.Flag("start-maximized", true),
+		chromedp.Flag("no-default-browser-check", true),
+		chromedp.Flag("no-first-run", true),
+	)
 
-	if err := chromedp.Run(ctx, network.Enable()); err != nil {
-		log.Fatalf("network enable: %v", err)
-	}
+	allocCtx, cancelAlloc := chromedp.NewExecAllocator(context.Background(), opts...)
+	ctx, cancelCtx := chromedp.NewContext(allocCtx)
+	s.ctx, s.cancels = ctx, []context.CancelFunc{cancelAlloc, cancelCtx}
 
-	if err := chromedp.Run(ctx, chromedp.Navigate(u)); err != nil {
-		log.Fatalf("navigate error: %v", err)
+	if err := withRetry(ctx, "inject stealth JS", func() error {
+		return chromedp.Run(ctx, chromedp.Evaluate(stealthScript, nil))
+	}); err != nil {
+		s.Close()
+		return err
 	}
-
-	var avatarExists bool
-	fmt.Println("Checking login status...")
-
-	if err := chromedp.Run(ctx,
-		chromedp.Sleep(3*time.Second),
-		chromedp.Evaluate(`document.querySelector('div[class*="_avatar_"][role="img"]') !== null`, &avatarExists),
-	); err != nil {
-		log.Printf("Error checking login status: %v", err)
+	if err := withRetry(ctx, "enable network", func() error {
+		return chromedp.Run(ctx, network.Enable())
+	}); err != nil {
+		s.Close()
+		return err
+	}
+	if cfg.CaptureAPI {
+		s.capt = startCapture(ctx, cfg.CapturePattern)
+	}
+	if err := s.navigate(u); err != nil {
+		s.Close()
+		return err
 	}
 
-	if !avatarExists {
-		fmt.Println("Not logged in. Please complete login/navigation in the browser and press Enter to continue...")
-		bufio.NewReader(os.Stdin).ReadString('\n')
-	} else {
+	fmt.Println("Checking login status...")
+	if s.loggedIn() {
 		fmt.Println("Already logged in, continuing automatically...")
+		return nil
 	}
-
-	var html string
-	if err := chromedp.Run(ctx, chromedp.OuterHTML("html", &html)); err != nil {
-		log.Fatalf("extract html: %v", err)
+	if !interactive() {
+		s.Close()
+		return fmt.Errorf("%w, log in once from an interactive run to store the session in %s", ErrNotLoggedIn, cfg.UserDataDir)
+	}
+	fmt.Println("Not logged in. Please complete login in the browser and press Enter to continue...")
+	bufio.NewReader(os.Stdin).ReadString('\n')
+	if err := s.navigate(u); err != nil {
+		s.Close()
+		return err
+	}
+	if !s.loggedIn() {
+		s.Close()
+		return ErrNotLoggedIn
 	}
+	return nil
+}
 
-	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
-	if err != nil {
-		log.Fatalf("goquery parse: %v", err)
+func (s *Session) loggedIn() bool {
+	return chromedp.Run(s.ctx, chromedp.Poll(`document.querySelector('div[class*="_avatar_"][role="img"]') !== null`,
+		nil, chromedp.WithPollingTimeout(loginTimeout))) == nil
+}
+
+// interactive reports whether stdin is a terminal.
+func interactive() bool {
+	fi, err := os.Stdin.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+func (s *Session) navigate(u string) error {
+	return withRetry(s.ctx, "navigate", func() error {
+		return chromedp.Run(s.ctx, chromedp.Navigate(u))
+	})
+}
+
+// Run runs query on Censys and stores every host found. On error the
+// returned Stats still count the pages that were saved before it. A query
+// that completed within the cache TTL is answered from the database unless
+// refresh is set.
+func (s *Session) Run(query string, refresh bool) (Stats, error) {
+	if c, ok := cachedRun(query); ok && !refresh {
+		return cachedStats(c), nil
 	}
+	if err := checkBudget(); err != nil {
+		return Stats{}, err
+	}
+	audit.Record(audit.ActionUpdaterQuery, map[string]string{"query": query})
 
-	doc.Find("div[data-testid='hostDetailsCard']").Each(func(_ int, s *goquery.Selection) {
-		h := models.Host{Services: make(models.JSONServiceMap)}
+	u := "https://platform.censys.io/search?q=" + url.QueryEscape(query)
+	st := Stats{}
+	batch := time.Now().Format(time.RFC3339Nano)
+	warned := false
 
-		link := s.Find("a[title^='View']")
-		href, _ := link.Attr("href")
-		h.IP = filepath.Base(strings.Split(href, "?")[0])
-		h.Hostname = s.Find("._typographyDefault_80wah_2").First().Text()
+	if s.capt != nil {
+		// Drop what the previous page left behind.
+		s.capt.take()
+	}
+	if err := s.navigate(u); err != nil {
+		return st, err
+	}
+	ctx, capt := s.ctx, s.capt
+	if err := waitResults(ctx); err != nil {
+		// Captured API responses may still hold the hosts; the page
+		// parse below reports the outdated selectors either way.
+		if capt == nil || !errors.Is(err, ErrSelectorsOutdated) {
+			return st, err
+		}
+		fmt.Printf("[!] %v\n", err)
+	}
 
-		s.Find("div[data-testid='label-list'] span._label_13xbf_14").Each(func(_ int, lab *goquery.Selection) {
-			h.Labels = append(h.Labels, lab.Text())
-		})
+	for {
+		var html string
+		if err := withRetry(ctx, "extract html", func() error {
+			return chromedp.Run(ctx, chromedp.OuterHTML("html", &html))
+		}); err != nil {
+			return st, fmt.Errorf("page %d: %w", st.Pages+1, err)
+		}
 
-		h.Location = s.Find("table.qI1Kw td._typographyDefault_80wah_2").First().Text()
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+		if err != nil {
+			return st, fmt.Errorf("page %d: parse html: %w", st.Pages+1, err)
+		}
 
-		s.Find("div.jzMRW div._7nxyW a[title]").Each(func(_ int, svc *goquery.Selection) {
-			t := svc.Find("span._label_r9r80_24").Text()
-			parts := strings.Split(t, " / ")
-			if len(parts) >= 2 {
-				portStr := strings.TrimSpace(parts[0])
-				srv := strings.TrimSpace(parts[1])
-				p, _ := strconv.Atoi(portStr)
-				h.Services[srv] = p
+		st.Pages++
+		st.Credits += cfg.CreditsPerPage
+		spendCredits(query, st.Pages, &warned)
+		if st.Pages == 1 {
+			st.Available = parseAvailable(doc)
+		}
+		recs, domErr := selectors.parseCards(doc)
+		if capt != nil {
+			apiRecs := decodeCaptured(capt.take(), st.Pages)
+			st.FromAPI += len(apiRecs)
+			recs = append(recs, apiRecs...)
+			if domErr != nil && len(apiRecs) > 0 {
+				fmt.Printf("[!] Page %d: %v, using the %d hosts from captured API responses\n", st.Pages, domErr, len(apiRecs))
+				domErr = nil
 			}
-		})
-
-		var existing models.Host
-		r := datastore.DB.First(&existing, "ip = ?", h.IP)
-		if r.Error != nil && r.Error == gorm.ErrRecordNotFound {
-			datastore.DB.Create(&h)
-			newCount++
-		} else if r.Error == nil {
-			h.IP = existing.IP
-			datastore.DB.Save(&h)
-			updCount++
 		}
-	})
+		if domErr != nil {
+			return st, fmt.Errorf("page %d: %w", st.Pages, domErr)
+		}
+		pageNew, pageUpd := ingest(query, fmt.Sprintf("%s/p%d", batch, st.Pages), st.Pages, recs)
+		st.New += pageNew
+		st.Updated += pageUpd
+		st.Collected += pageNew + pageUpd
+		fmt.Printf("Page %d: %d results (%d new, %d updated), %s collected, %d credits spent\n",
+			st.Pages, pageNew+pageUpd, pageNew, pageUpd, st.progress(), st.Credits)
 
-	return
+		if !hasNextPage(ctx) {
+			break
+		}
+		if cfg.MaxPages > 0 && st.Pages >= cfg.MaxPages {
+			st.StoppedBy = StopMaxPages
+			break
+		}
+		if cfg.CreditBudget > 0 && st.Credits+cfg.CreditsPerPage > cfg.CreditBudget {
+			st.StoppedBy = StopCreditBudget
+			break
+		}
+		if err := checkBudget(); err != nil {
+			fmt.Printf("[!] %v\n", err)
+			st.StoppedBy = StopCreditLimit
+			break
+		}
+		if err := nextPage(ctx, doc); err != nil {
+			fmt.Printf("[!] Stopping after page %d: %v\n", st.Pages, err)
+			st.StoppedBy = StopPagination
+			break
+		}
+	}
+
+	if st.StoppedBy != "" {
+		fmt.Printf("[!] Stopped early (%s): collected %s results\n", st.StoppedBy, st.progress())
+	}
+	switch st.StoppedBy {
+	case StopCreditBudget, StopCreditLimit, StopPagination:
+		// Incomplete, the next run should fetch it again.
+	default:
+		storeCache(query, st)
+	}
+	audit.Record(audit.ActionImport, map[string]string{
+		"query":      query,
+		"new":        strconv.Itoa(st.New),
+		"updated":    strconv.Itoa(st.Updated),
+		"pages":      strconv.Itoa(st.Pages),
+		"collected":  strconv.Itoa(st.Collected),
+		"available":  strconv.Itoa(st.Available),
+		"stopped_by": st.StoppedBy,
+		"from_api":   strconv.Itoa(st.FromAPI),
+	})
+	return st, nil
 }
@@ -0,0 +1,189 @@
+// core/scraper/selectors.go
+package scraper
+
+import (
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"gopkg.in/yaml.v3"
+
+	"smuggr.xyz/thughunter/common/models"
+)
+
+const SelectorsVersion = 1
+
+//go:embed selectors.yaml
+var DefaultSelectors []byte
+
+var ErrSelectorsOutdated = errors.New("the result page selectors are probably outdated")
+
+// noResultsText is what Censys shows instead of cards for an empty search.
+const noResultsText = "no results"
+
+type Selectors struct {
+	Version          int    `yaml:"version"`
+	Card             string `yaml:"card"`
+	Link             string `yaml:"link"`
+	Hostname         string `yaml:"hostname"`
+	Label            string `yaml:"label"`
+	Location         string `yaml:"location"`
+	Service          string `yaml:"service"`
+	ServiceLabel     string `yaml:"service_label"`
+	ServiceSeparator string `yaml:"service_separator"`
+	NextPage         string `yaml:"next_page"`
+
+	hash string
+}
+
+var selectors = mustParseSelectors(DefaultSelectors)
+
+// LoadSelectors reads a selector file, or returns the built-in selectors
+// when path is empty.
+func LoadSelectors(path string) (Selectors, error) {
+	if path == "" {
+		return ParseSelectors(DefaultSelectors)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Selectors{}, err
+	}
+	s, err := ParseSelectors(data)
+	if err != nil {
+		return Selectors{}, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	return s, nil
+}
+
+func ParseSelectors(data []byte) (Selectors, error) {
+	var s Selectors
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return Selectors{}, err
+	}
+	if s.Version != SelectorsVersion {
+		return Selectors{}, fmt.Errorf("unsupported selectors version %d, expected %d", s.Version, SelectorsVersion)
+	}
+	required := map[string]string{
+		"card":          s.Card,
+		"link":          s.Link,
+		"service":       s.Service,
+		"service_label": s.ServiceLabel,
+		"next_page":     s.NextPage,
+	}
+	for name, v := range required {
+		if strings.TrimSpace(v) == "" {
+			return Selectors{}, fmt.Errorf("selector %s is empty", name)
+		}
+	}
+	if s.ServiceSeparator == "" {
+		s.ServiceSeparator = " / "
+	}
+	sum := sha256.Sum256(data)
+	s.hash = hex.EncodeToString(sum[:4])
+	return s, nil
+}
+
+// ParserVersion identifies the selectors a record was parsed with, so
+// records can be told apart after the selector file is edited.
+func (sel Selectors) ParserVersion() string {
+	return fmt.Sprintf("selectors/v%d-%s", sel.Version, sel.hash)
+}
+
+func mustParseSelectors(data []byte) Selectors {
+	s, err := ParseSelectors(data)
+	if err != nil {
+		panic("built-in selectors: " + err.Error())
+	}
+	return s
+}
+
+// ParseResults extracts the hosts from a Censys result page using the
+// configured selectors. Cards without a valid IP are skipped; a page with
+// cards but no parsable hosts, hosts none of which have services, or
+// neither cards nor a "no results" message returns ErrSelectorsOutdated.
+func ParseResults(html string) ([]models.Host, error) {
+	return selectors.Parse(html)
+}
+
+func (sel Selectors) Parse(html string) ([]models.Host, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return nil, fmt.Errorf("parse html: %w", err)
+	}
+	return sel.parseDocument(doc)
+}
+
+func (sel Selectors) parseDocument(doc *goquery.Document) ([]models.Host, error) {
+	recs, err := sel.parseCards(doc)
+	if err != nil {
+		return nil, err
+	}
+	hosts := make([]models.Host, len(recs))
+	for i, r := range recs {
+		hosts[i] = r.Host
+	}
+	return hosts, nil
+}
+
+func (sel Selectors) parseCards(doc *goquery.Document) ([]parsedRecord, error) {
+	var recs []parsedRecord
+	cards := doc.Find(sel.Card)
+	cards.Each(func(_ int, s *goquery.Selection) {
+		h := models.Host{Services: make(models.JSONServiceMap)}
+
+		href, _ := s.Find(sel.Link).First().Attr("href")
+		h.IP = filepath.Base(strings.Split(href, "?")[0])
+		if net.ParseIP(h.IP) == nil {
+			return
+		}
+		if sel.Hostname != "" {
+			h.Hostname = strings.TrimSpace(s.Find(sel.Hostname).First().Text())
+		}
+		if sel.Label != "" {
+			s.Find(sel.Label).Each(func(_ int, lab *goquery.Selection) {
+				h.Labels = append(h.Labels, strings.TrimSpace(lab.Text()))
+			})
+		}
+		if sel.Location != "" {
+			h.Location = strings.TrimSpace(s.Find(sel.Location).First().Text())
+		}
+
+		s.Find(sel.Service).Each(func(_ int, svc *goquery.Selection) {
+			parts := strings.Split(svc.Find(sel.ServiceLabel).Text(), sel.ServiceSeparator)
+			if len(parts) < 2 {
+				return
+			}
+			p, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+			if err != nil {
+				return
+			}
+			h.Services[strings.TrimSpace(parts[1])] = p
+		})
+		raw, _ := goquery.OuterHtml(s)
+		recs = append(recs, parsedRecord{Source: SourceHTML, Version: sel.ParserVersion(), Host: h, Raw: []byte(raw)})
+	})
+
+	if cards.Length() == 0 && !strings.Contains(strings.ToLower(doc.Text()), noResultsText) {
+		return nil, fmt.Errorf("neither result cards nor %q on the page: %w", noResultsText, ErrSelectorsOutdated)
+	}
+	if cards.Length() > 0 && len(recs) == 0 {
+		return nil, fmt.Errorf("%d result cards but no hosts parsed: %w", cards.Length(), ErrSelectorsOutdated)
+	}
+	for _, r := range recs {
+		if len(r.Host.Services) > 0 {
+			return recs, nil
+		}
+	}
+	if len(recs) > 0 {
+		return nil, fmt.Errorf("%d hosts parsed without any services: %w", len(recs), ErrSelectorsOutdated)
+	}
+	return recs, nil
+}
@@ -0,0 +1,128 @@
+package scraper
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"smuggr.xyz/thughunter/common/models"
+)
+
+func TestParseResults(t *testing.T) {
+	builtin, err := LoadSelectors("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	custom, err := LoadSelectors(filepath.Join("testdata", "custom.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		sel     Selectors
+		page    string
+		want    []models.Host
+		wantErr error
+	}{
+		{
+			name: "cards",
+			sel:  builtin,
+			page: "results.html",
+			want: []models.Host{
+				{
+					IP:       "198.51.100.7",
+					Hostname: "vnc.example.net",
+					Location: "Berlin, Germany",
+					Labels:   models.JSONStringSlice{"remote-access", "login-page"},
+					Services: models.JSONServiceMap{"VNC": 5900, "HTTP": 80},
+				},
+				{
+					IP:       "2001:db8::7",
+					Services: models.JSONServiceMap{"VNC": 5901},
+				},
+			},
+		},
+		{
+			name:    "cards but no hosts",
+			sel:     builtin,
+			page:    "outdated.html",
+			wantErr: ErrSelectorsOutdated,
+		},
+		{
+			name:    "hosts without services",
+			sel:     builtin,
+			page:    "no_services.html",
+			wantErr: ErrSelectorsOutdated,
+		},
+		{
+			name: "no results",
+			sel:  builtin,
+			page: "no_results.html",
+		},
+		{
+			name: "custom selectors",
+			sel:  custom,
+			page: "custom.html",
+			want: []models.Host{
+				{
+					IP:       "203.0.113.20",
+					Hostname: "desk.example.org",
+					Location: "Lyon, France",
+					Services: models.JSONServiceMap{"VNC": 5900, "SSH": 22},
+				},
+			},
+		},
+		{
+			name:    "custom selectors on the default markup",
+			sel:     custom,
+			page:    "results.html",
+			wantErr: ErrSelectorsOutdated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			html, err := os.ReadFile(filepath.Join("testdata", tt.page))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := tt.sel.Parse(string(html))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d hosts, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i := range got {
+				if !reflect.DeepEqual(got[i], tt.want[i]) {
+					t.Errorf("host %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLoadSelectorsRejectsBadFiles(t *testing.T) {
+	dir := t.TempDir()
+	for name, data := range map[string]string{
+		"version": "version: 2\ncard: a\nlink: a\nservice: a\nservice_label: a\nnext_page: a\n",
+		"missing": "version: 1\ncard: a\nlink: a\n",
+	} {
+		path := filepath.Join(dir, name+".yaml")
+		if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadSelectors(path); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+// core/workspace/workspace.go
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	Root       = "./workspaces"
+	activeFile = ".active"
+	metaFile   = "workspace.yaml"
+)
+
+var (
+	ErrNotFound = errors.New("workspace not found")
+	ErrArchived = errors.New("workspace is archived")
+
+	validName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+)
+
+type Branding struct {
+	Client      string `yaml:"client,omitempty"`
+	ReportTitle string `yaml:"report_title,omitempty"`
+	Footer      string `yaml:"footer,omitempty"`
+}
+
+type Workspace struct {
+	Name       string     `yaml:"name"`
+	Created    time.Time  `yaml:"created"`
+	ArchivedAt *time.Time `yaml:"archived_at,omitempty"`
+	Branding   Branding   `yaml:"branding"`
+
+	Dir string `yaml:"-"`
+}
+
+func (w Workspace) Archived() bool {
+	return w.ArchivedAt != nil
+}
+
+// Path returns name inside the workspace directory.
+func (w Workspace) Path(name string) string {
+	return filepath.Join(w.Dir, name)
+}
+
+// Title is the heading used for menus and reports.
+func (w Workspace) Title() string {
+	if w.Branding.Client != "" {
+		return fmt.Sprintf("%s (%s)", w.Name, w.Branding.Client)
+	}
+	return w.Name
+}
+
+func Create(name string, b Branding) (Workspace, error) {
+	if !validName.MatchString(name) {
+		return Workspace{}, fmt.Errorf("invalid workspace name %q, use lowercase letters, digits, - and _", name)
+	}
+	w := Workspace{Name: name, Created: time.Now(), Branding: b, Dir: filepath.Join(Root, name)}
+	if _, err := os.Stat(w.Dir); err == nil {
+		return Workspace{}, fmt.Errorf("workspace %s already exists", name)
+	}
+	if err := os.MkdirAll(w.Path("scans"), 0700); err != nil {
+		return Workspace{}, err
+	}
+	scope := "# One IP or CIDR per line for " + w.Title() + "\n"
+	if err := os.WriteFile(w.Path("scope.txt"), []byte(scope), 0600); err != nil {
+		return Workspace{}, err
+	}
+	return w, save(w)
+}
+
+func Load(name string) (Workspace, error) {
+	dir := filepath.Join(Root, name)
+	data, err := os.ReadFile(filepath.Join(dir, metaFile))
+	if os.IsNotExist(err) || !validName.MatchString(name) {
+		return Workspace{}, fmt.Errorf("%w: %s", ErrNotFound, name)
+	} else if err != nil {
+		return Workspace{}, err
+	}
+	var w Workspace
+	if err := yaml.Unmarshal(data, &w); err != nil {
+		return Workspace{}, fmt.Errorf("%s: %w", filepath.Join(dir, metaFile), err)
+	}
+	w.Name, w.Dir = name, dir
+	return w, nil
+}
+
+func List() ([]Workspace, error) {
+	entries, err := os.ReadDir(Root)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var out []Workspace
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		w, err := Load(e.Name())
+		if errors.Is(err, ErrNotFound) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		out = append(out, w)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+// Active returns the name of the workspace selected with Use, or "" when
+// none is selected.
+func Active() (string, error) {
+	data, err := os.ReadFile(filepath.Join(Root, activeFile))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	return strings.TrimSpace(string(data)), err
+}
+
+func Use(name string) error {
+	w, err := Load(name)
+	if err != nil {
+		return err
+	}
+	if w.Archived() {
+		return fmt.Errorf("%w: %s", ErrArchived, name)
+	}
+	return os.WriteFile(filepath.Join(Root, activeFile), []byte(name+"\n"), 0600)
+}
+
+// Archive marks a workspace read-only for selection. Its data stays on disk
+// and it is deselected if it was active.
+func Archive(name string) error {
+	w, err := Load(name)
+	if err != nil {
+		return err
+	}
+	if w.Archived() {
+		return fmt.Errorf("%w: %s", ErrArchived, name)
+	}
+	now := time.Now()
+	w.ArchivedAt = &now
+	if err := save(w); err != nil {
+		return err
+	}
+	if active, _ := Active(); active == name {
+		return os.Remove(filepath.Join(Root, activeFile))
+	}
+	return nil
+}
+
+func save(w Workspace) error {
+	data, err := yaml.Marshal(w)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(w.Path(metaFile), data, 0600)
+}
@@ -3,39 +3,73 @@ package main
 
 import (
 	"bufio"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
 
 	"github.com/joho/godotenv"
 	"smuggr.xyz/thughunter/common/ui"
+	"smuggr.xyz/thughunter/core/audit"
+	"smuggr.xyz/thughunter/core/config"
+	"smuggr.xyz/thughunter/core/control"
 	"smuggr.xyz/thughunter/core/datastore"
+	"smuggr.xyz/thughunter/core/enrich"
+	"smuggr.xyz/thughunter/core/notify"
+	"smuggr.xyz/thughunter/core/queries"
 	"smuggr.xyz/thughunter/core/scanner"
+	"smuggr.xyz/thughunter/core/scraper"
 )
 
-var predefined = []string{
-	`host.services.vnc.security_types.value = "1" and host.operating_system.product = "linux"`,
-	`host.services.vnc.security_types.value = "1" and host.services.vnc.desktop_name= "QEMU"`,
-	`host.services.vnc.security_types.value = "1" and host.services.vnc.security_types.name = "None"`,
-	`host.services.vnc.security_types.value = "1" and (host.operating_system.product = "linux" or host.operating_system.product = "unix")`,
-	`host.services.vnc.security_types.name = "None"`,
-}
-
 func main() {
 	fmt.Println("Starting ThugHunter...")
-	loadEnvAndInitDB()
+	cfg, args := loadConfig()
+	initSubsystems(cfg)
+	queries.SeedDefaults()
+	if len(args) > 0 {
+		os.Exit(ui.RunCommand(args))
+	}
 	r := bufio.NewReader(os.Stdin)
+	ui.RegisterDashboard()
 	scanner.StartControlServer()
-	ui.MainMenuLoop(r, predefined)
+	ui.MainMenuLoop(r)
 }
 
-func loadEnvAndInitDB() {
+func loadConfig() (*config.Config, []string) {
 	if err := godotenv.Load(); err != nil {
 		fmt.Println("No .env file found, using defaults")
 	}
-	dbPath := os.Getenv("DB_PATH")
-	if dbPath == "" {
-		dbPath = "./thughunter.db"
+	cfg, args, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	} else if err != nil {
+		fmt.Println("Invalid configuration:", err)
+		os.Exit(2)
+	}
+	for _, n := range cfg.Notices {
+		fmt.Println("[!]", n)
+	}
+	return cfg, args
+}
+
+func initSubsystems(cfg *config.Config) {
+	if cfg.Workspace.Name != "" {
+		fmt.Printf("Workspace: %s\n", cfg.Workspace.Title())
+	}
+	fmt.Printf("Initializing database: %s\n", cfg.Database.Path)
+	datastore.Initialize(cfg.Database.Path)
+	datastore.InitializeLedger(cfg.Scraper.LedgerPath, cfg.Workspace.Name)
+	audit.Initialize(cfg.Audit)
+	control.Configure(cfg.Control)
+	if err := scanner.Configure(cfg.Scanner); err != nil {
+		fmt.Println("Invalid configuration:", err)
+		os.Exit(2)
+	}
+	if err := scraper.Configure(cfg.Scraper); err != nil {
+		fmt.Println("Invalid configuration:", err)
+		os.Exit(2)
 	}
-	fmt.Printf("Initializing database: %s\n", dbPath)
-	datastore.Initialize(dbPath)
+	notify.Configure(cfg.Notify)
+	enrich.Configure(cfg.GeoIP)
+	ui.Configure(cfg)
 }